@@ -0,0 +1,296 @@
+/**
+ * The cassette package records RIS traffic to a file and replays it, so
+ * tests built on GetResponse can run offline and deterministically.
+ *
+ *	rec, err := cassette.New("testdata/inquiry.json", cassette.ModeReplay)
+ *	s := settings.New(merchantID, "https://risk.test.kount.net", apiKey, configKey)
+ *	s.SetTransport(rec)
+ *	...
+ *	err = rec.Save() // in ModeRecord, writes the cassette file
+ *
+ * Recorded requests have the API key, payment token and customer personal
+ * data redacted. Replayed requests are matched on MODE, SESS and ORDR.
+ */
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/phpsquid/kount/response"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+type Mode int
+
+const (
+	ModeReplay Mode = iota // serve recorded responses, never touch the network
+	ModeRecord             // send requests and record them
+)
+
+// Redacted replaces the value of a redacted field.
+const Redacted = response.Redacted
+
+var ErrNoInteraction = errors.New("cassette: no recorded interaction matches the request")
+
+// Request fields redacted by default, the same set Response.Request redacts.
+var DefaultRedactedFields = response.RedactedRequestFields
+
+// response fields redacted by default
+var DefaultRedactedResponseFields = []string{"IP_IPAD", "PIP_IPAD", "UAS"}
+
+// request headers redacted by default
+var DefaultRedactedHeaders = []string{"X-Kount-Api-Key"}
+
+// fields a replayed request is matched on
+var matchFields = []string{"MODE", "SESS", "ORDR"}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Form   url.Values  `json:"form"`
+}
+
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// Interaction is a recorded request and the response it received.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records or replays RIS traffic.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	redactedFields         []string
+	redactedResponseFields []string
+	redactedHeaders        []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a recorder for the cassette file at path. In ModeReplay the
+// file is loaded and must exist.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		path:                   path,
+		mode:                   mode,
+		transport:              http.DefaultTransport,
+		redactedFields:         DefaultRedactedFields,
+		redactedResponseFields: DefaultRedactedResponseFields,
+		redactedHeaders:        DefaultRedactedHeaders,
+	}
+	if mode == ModeReplay {
+		if err := r.load(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Set the transport requests are sent with in ModeRecord.
+func (r *Recorder) SetTransport(transport http.RoundTripper) {
+	r.transport = transport
+}
+
+// Set the request fields redacted in recordings, replacing the defaults.
+// Fields ending in '*' match every key with that prefix.
+func (r *Recorder) SetRedactedFields(fields ...string) {
+	r.redactedFields = fields
+}
+
+// Set the response fields redacted in recordings, replacing the defaults.
+// Fields ending in '*' match every key with that prefix.
+func (r *Recorder) SetRedactedResponseFields(fields ...string) {
+	r.redactedResponseFields = fields
+}
+
+// Get the recorded interactions.
+func (r *Recorder) GetInteractions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interactions := make([]Interaction, len(r.cassette.Interactions))
+	copy(interactions, r.cassette.Interactions)
+	return interactions
+}
+
+func (r *Recorder) load() error {
+	body, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, &r.cassette); err != nil {
+		return fmt.Errorf("cassette: %s: %v", r.path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return nil
+}
+
+// Save writes the recorded interactions to the cassette file.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(body, '\n'), 0644)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	form, err := readForm(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, form)
+	}
+	return r.record(req, form)
+}
+
+// Read the form body of a request and restore the body for sending.
+func readForm(req *http.Request) (url.Values, error) {
+	if req.Body == nil {
+		return url.Values{}, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return url.ParseQuery(string(body))
+}
+
+func (r *Recorder) replay(req *http.Request, form url.Values) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !matches(interaction.Request.Form, form) {
+			continue
+		}
+		r.used[i] = true
+		recorded := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+			StatusCode:    recorded.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: MODE=%q SESS=%q ORDR=%q", ErrNoInteraction, form.Get("MODE"), form.Get("SESS"), form.Get("ORDR"))
+}
+
+func matches(recorded, form url.Values) bool {
+	for _, key := range matchFields {
+		if recorded.Get(key) != form.Get(key) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) record(req *http.Request, form url.Values) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: r.redactHeader(req.Header),
+			Form:   r.redactForm(form),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: resp.Header.Clone(),
+			Body:   r.redactBody(string(body)),
+		},
+	}
+	// redaction may change the body length
+	interaction.Response.Header.Del("Content-Length")
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.used = append(r.used, false)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, key := range r.redactedHeaders {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, Redacted)
+		}
+	}
+	return redacted
+}
+
+func (r *Recorder) redactForm(form url.Values) url.Values {
+	redacted := url.Values{}
+	for key, values := range form {
+		redacted[key] = append([]string(nil), values...)
+	}
+	for key := range redacted {
+		if response.FieldMatches(r.redactedFields, key) {
+			redacted.Set(key, Redacted)
+		}
+	}
+	return redacted
+}
+
+// Redact response fields. Bodies that cannot be parsed are kept as they are.
+func (r *Recorder) redactBody(body string) string {
+	resp := &response.Response{Raw: body}
+	if err := resp.Digest(); err != nil {
+		return body
+	}
+	changed := false
+	for key := range resp.Data {
+		if response.FieldMatches(r.redactedResponseFields, key) {
+			resp.Data[key] = Redacted
+			changed = true
+		}
+	}
+	if !changed {
+		return body
+	}
+	encoded, err := response.Encode(resp.Data, resp.Format)
+	if err != nil {
+		return body
+	}
+	return encoded
+}
@@ -0,0 +1,65 @@
+package cassette_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phpsquid/kount/cassette"
+	"github.com/phpsquid/kount/kounttest"
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/response"
+)
+
+func TestRecordRedactsAndReplays(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+	srv.SetDefault(kounttest.Reply{Auto: response.DecisionReview, Score: 55})
+
+	path := filepath.Join(t.TempDir(), "inquiry.json")
+	rec, err := cassette.New(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := srv.Settings()
+	s.SetTransport(rec)
+
+	inquiry := request.NewInquiry(s)
+	inquiry.SetSessionID("0123456789abcdef0123456789abcdef")
+	inquiry.SetOrderNumber("order-1")
+	inquiry.SetUnique("customer-8812")
+	inquiry.SetCanonicalEmailUDF("CANONICAL_EMAIL")
+	inquiry.SetEmail("Jane.Doe+shop@gmail.com")
+	inquiry.SetBillingAddress("1 Main St", "", "Springfield", "IL", "62701", "US", "", "")
+	if _, err := inquiry.GetResponse(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"test-api-key", "janedoe", "jane.doe", "customer-8812", "Springfield", "62701", "Main St"} {
+		if strings.Contains(strings.ToLower(string(saved)), strings.ToLower(secret)) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	replay, err := cassette.New(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+	s.SetTransport(replay)
+	resp, err := inquiry.GetResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetDecision() != response.DecisionReview || resp.GetScore() != "55" {
+		t.Errorf("replayed AUTO=%s SCOR=%s", resp.GetAuto(), resp.GetScore())
+	}
+}
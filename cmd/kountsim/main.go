@@ -0,0 +1,42 @@
+// Command kountsim runs the RIS simulator as a standalone HTTP server.
+//
+//	kountsim -addr :8080 -config rules.json
+//
+// Point settings.New at http://localhost:8080 to send inquiries to it.
+package main
+
+import (
+	"flag"
+	"github.com/phpsquid/kount/simulator"
+	"log"
+	"net/http"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	configPath := flag.String("config", "", "JSON rules config; the built-in rules are used when empty")
+	merchantID := flag.String("merchant", "", "merchant id requests must carry (any when empty)")
+	apiKey := flag.String("api-key", "", "API key requests must carry (any when empty)")
+	flag.Parse()
+
+	config := simulator.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if config, err = simulator.LoadConfig(*configPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *merchantID != "" {
+		config.MerchantID = *merchantID
+	}
+	if *apiKey != "" {
+		config.APIKey = *apiKey
+	}
+
+	sim, err := simulator.New(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("kountsim listening on %s with %d rules", *addr, len(config.Rules))
+	log.Fatal(http.ListenAndServe(*addr, sim))
+}
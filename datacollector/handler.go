@@ -0,0 +1,143 @@
+package datacollector
+
+import (
+	"github.com/phpsquid/kount/settings"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TestURL       = settings.TestDataCollectorURL
+	ProductionURL = settings.ProductionDataCollectorURL
+	sessionMaxAge = 30 * 24 * time.Hour
+)
+
+// Handler serves the logo.htm and logo.gif endpoints embedded in the
+// storefront page and redirects them to Kount's data collector with the
+// merchant id and the session id. The session id is kept in a cookie so the
+// inquiry can later be sent with the same SESS value. The redirect does not
+// mean the browser reached the collector; to record completed collections,
+// serve the collector through a Proxy with a Store. Requests fail while the
+// settings' endpoints do not pass Settings.CheckEndpoint.
+type Handler struct {
+	settings     *settings.Settings
+	cookieName   string
+	secureCookie bool
+}
+
+func NewHandler(settings *settings.Settings) *Handler {
+	return &Handler{
+		settings:   settings,
+		cookieName: DefaultCookieName,
+	}
+}
+
+// Set the name of the cookie used to store the session id.
+func (h *Handler) SetCookieName(name string) {
+	h.cookieName = name
+}
+
+/**
+ * Always mark the session cookie Secure. By default it is only Secure when
+ * the request arrived over TLS, which is never the case behind a proxy that
+ * terminates TLS.
+ */
+func (h *Handler) SetSecureCookie(secure bool) {
+	h.secureCookie = secure
+}
+
+// Get the name of the cookie used to store the session id.
+func (h *Handler) GetCookieName() string {
+	return h.cookieName
+}
+
+// Get the data collector URL, defaulting to the environment's collector.
+func (h *Handler) collectorURL() (string, error) {
+	u, err := checkedCollectorURL(h.settings)
+	return strings.TrimRight(u, "/"), err
+}
+
+/**
+ * Get the collector URL after checking the settings' endpoints against their
+ * environment and the production guard, including the production collector
+ * used by default in the production environment.
+ */
+func checkedCollectorURL(s *settings.Settings) (string, error) {
+	if err := s.CheckEndpoint(); err != nil {
+		return "", err
+	}
+	u := defaultCollectorURL(s)
+	if u == ProductionURL && settings.ProductionForbidden() {
+		return "", settings.ErrProductionForbidden
+	}
+	return u, nil
+}
+
+// Get the configured data collector URL or the environment's collector.
+func defaultCollectorURL(s *settings.Settings) string {
+	if u := s.GetDataCollectorURL(); u != "" {
+		return u
+	}
+	if s.GetEnvironment() == settings.EnvironmentProduction {
+		return ProductionURL
+	}
+	return TestURL
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var page string
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/logo.htm"):
+		page = "/logo.htm"
+	case strings.HasSuffix(r.URL.Path, "/logo.gif"):
+		page = "/logo.gif"
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	collector, err := h.collectorURL()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sessionID, err := h.session(w, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	query := url.Values{}
+	query.Set("m", h.settings.GetMerchantID())
+	query.Set("s", sessionID)
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, collector+page+"?"+query.Encode(), http.StatusFound)
+}
+
+// Get the session id from the cookie, or generate a new one and set the
+// cookie if the request does not carry a valid session id.
+func (h *Handler) session(w http.ResponseWriter, r *http.Request) (string, error) {
+	if id, err := SessionFromRequest(r, h.cookieName); err == nil {
+		return id, nil
+	}
+
+	id, err := NewSessionID()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.cookieName,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(sessionMaxAge / time.Second),
+		Secure:   h.secureCookie || r.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id, nil
+}
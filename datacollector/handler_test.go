@@ -0,0 +1,93 @@
+package datacollector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/phpsquid/kount/settings"
+)
+
+func TestHandlerRedirectsToCollector(t *testing.T) {
+	s := settings.New("123456", "https://risk.test.kount.net", "key", "config-key-12345")
+	h := NewHandler(s)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/kount/logo.htm", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("got status %d", rec.Code)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := location.Query().Get("s")
+	if location.Host != "tst.kaxsdc.com" || location.Path != "/logo.htm" ||
+		location.Query().Get("m") != "123456" || !ValidSessionID(session) {
+		t.Errorf("got redirect to %s", location)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultCookieName || cookies[0].Value != session {
+		t.Errorf("got cookies %v", cookies)
+	}
+}
+
+func TestHandlerKeepsSession(t *testing.T) {
+	const id = "0123456789abcdefABCDEF0123456789"
+	s := settings.New("123456", "https://risk.test.kount.net", "key", "config-key-12345")
+	s.SetDataCollectorURL("https://collector.example.com/")
+	h := NewHandler(s)
+	h.SetCookieName("sess")
+
+	r := httptest.NewRequest("GET", "/logo.gif", nil)
+	r.AddCookie(&http.Cookie{Name: "sess", Value: id})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	want := "https://collector.example.com/logo.gif?m=123456&s=" + id
+	if got := rec.Header().Get("Location"); got != want {
+		t.Errorf("got redirect to %q, want %q", got, want)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Errorf("cookie set again: %v", rec.Result().Cookies())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/other", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown path: got status %d", rec.Code)
+	}
+}
+
+func TestHandlerRefusesForbiddenProduction(t *testing.T) {
+	// the other tests only use test endpoints
+	settings.ForbidProduction()
+	s := settings.NewForEnvironment(settings.EnvironmentProduction, "123456", "key", "config-key-12345")
+	rec := httptest.NewRecorder()
+	NewHandler(s).ServeHTTP(rec, httptest.NewRequest("GET", "/logo.htm", nil))
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Location") != "" {
+		t.Errorf("got status %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if _, err := NewProxy(s, "/kount"); err != settings.ErrProductionForbidden {
+		t.Errorf("NewProxy: got %v", err)
+	}
+}
+
+func TestHandlerSecureCookie(t *testing.T) {
+	h := NewHandler(settings.New("123456", "https://risk.test.kount.net", "key", "config-key-12345"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/logo.htm", nil))
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].Secure {
+		t.Errorf("plain HTTP: got cookies %v", cookies)
+	}
+
+	// behind a TLS terminating proxy the request itself is plain HTTP
+	h.SetSecureCookie(true)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/logo.htm", nil))
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || !cookies[0].Secure {
+		t.Errorf("SetSecureCookie: got cookies %v", cookies)
+	}
+}
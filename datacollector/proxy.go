@@ -0,0 +1,124 @@
+package datacollector
+
+import (
+	"github.com/phpsquid/kount/settings"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/**
+ * Proxy serves the data collector script and beacon requests from the
+ * merchant's own domain and forwards them to the collector host configured in
+ * settings. Requests below the prefix are sent upstream with the prefix
+ * removed, e.g. "/kount/collect/sdk" is forwarded as "/collect/sdk".
+ */
+type Proxy struct {
+	prefix   string
+	upstream *url.URL
+	proxy    *httputil.ReverseProxy
+	store    Store
+}
+
+func NewProxy(settings *settings.Settings, prefix string) (*Proxy, error) {
+	collector, err := checkedCollectorURL(settings)
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := url.Parse(collector)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Proxy{
+		prefix:   "/" + strings.Trim(prefix, "/"),
+		upstream: upstream,
+	}
+	if p.prefix == "/" {
+		p.prefix = ""
+	}
+	p.proxy = &httputil.ReverseProxy{
+		Director:       p.direct,
+		ModifyResponse: p.modifyResponse,
+	}
+	return p, nil
+}
+
+// Set the transport used to reach the collector host.
+func (p *Proxy) SetTransport(transport http.RoundTripper) {
+	p.proxy.Transport = transport
+}
+
+/**
+ * Set the store recording which sessions have reached the collector. A hit is
+ * recorded for every successful upstream response to a request carrying the
+ * session id in its "s" query parameter.
+ */
+func (p *Proxy) SetStore(store Store) {
+	p.store = store
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p.prefix != "" && r.URL.Path != p.prefix && !strings.HasPrefix(r.URL.Path, p.prefix+"/") {
+		http.NotFound(w, r)
+		return
+	}
+	p.proxy.ServeHTTP(w, r)
+}
+
+// Rewrite the incoming request so it targets the collector host.
+func (p *Proxy) direct(r *http.Request) {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+	if r.TLS != nil {
+		r.Header.Set("X-Forwarded-Proto", "https")
+	} else {
+		r.Header.Set("X-Forwarded-Proto", "http")
+	}
+	r.Header.Set("X-Forwarded-Host", r.Host)
+	r.Header.Set("X-Real-IP", clientIP)
+	// the storefront's cookies are of no use to the collector
+	r.Header.Del("Cookie")
+
+	path := strings.TrimPrefix(r.URL.Path, p.prefix)
+	if path == "" {
+		path = "/"
+	}
+	r.URL.Scheme = p.upstream.Scheme
+	r.URL.Host = p.upstream.Host
+	r.URL.Path = strings.TrimRight(p.upstream.Path, "/") + path
+	r.URL.RawPath = ""
+	r.Host = p.upstream.Host
+}
+
+// Record the collector hit and point redirects issued by the collector host
+// back through the proxy.
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	if p.store != nil && resp.StatusCode < http.StatusBadRequest {
+		if id := resp.Request.URL.Query().Get("s"); ValidSessionID(id) {
+			if err := p.store.MarkCollected(id, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return nil
+	}
+	target, err := url.Parse(location)
+	if err != nil || target.Host != p.upstream.Host {
+		return nil
+	}
+	path := strings.TrimPrefix(target.Path, strings.TrimRight(p.upstream.Path, "/"))
+	target.Scheme = ""
+	target.Host = ""
+	target.Path = p.prefix + path
+	resp.Header.Set("Location", target.String())
+	return nil
+}
@@ -0,0 +1,99 @@
+package datacollector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/phpsquid/kount/settings"
+)
+
+// start a collector stand-in and a proxy to it below /kount
+func newTestProxy(t *testing.T, upstream http.HandlerFunc) (*Proxy, *httptest.Server) {
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+
+	s := settings.New("123456", "https://risk.test.kount.net", "key", "config-key-12345")
+	s.SetDataCollectorURL(server.URL + "/collector")
+	proxy, err := NewProxy(s, "/kount/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proxy, server
+}
+
+func TestProxyForwardsClientHeaders(t *testing.T) {
+	var got *http.Request
+	proxy, _ := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte("collected"))
+	})
+
+	r := httptest.NewRequest("GET", "http://shop.example.com/kount/collect/sdk?m=123456", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	r.AddCookie(&http.Cookie{Name: "storefront", Value: "secret"})
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "collected" {
+		t.Fatalf("got %d %q", rec.Code, rec.Body.String())
+	}
+	if got.URL.Path != "/collector/collect/sdk" || got.URL.RawQuery != "m=123456" {
+		t.Errorf("forwarded to %s", got.URL)
+	}
+	headers := map[string]string{
+		"X-Real-Ip":         "203.0.113.7",
+		"X-Forwarded-For":   "203.0.113.7",
+		"X-Forwarded-Host":  "shop.example.com",
+		"X-Forwarded-Proto": "http",
+		"Cookie":            "",
+	}
+	for name, want := range headers {
+		if value := got.Header.Get(name); value != want {
+			t.Errorf("%s: got %q, want %q", name, value, want)
+		}
+	}
+}
+
+func TestProxyRewritesCollectorRedirects(t *testing.T) {
+	var upstreamURL string
+	proxy, server := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, upstreamURL+"/collector/logo.gif?s=1", http.StatusFound)
+	})
+	upstreamURL = server.URL
+
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, httptest.NewRequest("GET", "/kount/logo.htm", nil))
+	if location := rec.Header().Get("Location"); location != "/kount/logo.gif?s=1" {
+		t.Errorf("got Location %q", location)
+	}
+
+	rec = httptest.NewRecorder()
+	proxy.ServeHTTP(rec, httptest.NewRequest("GET", "/kountx/logo.htm", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("outside the prefix: got status %d", rec.Code)
+	}
+}
+
+func TestProxyRecordsSuccessfulCollection(t *testing.T) {
+	status := http.StatusOK
+	proxy, _ := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+	store := NewMemoryStore()
+	proxy.SetStore(store)
+
+	failed, _ := NewSessionID()
+	status = http.StatusBadGateway
+	proxy.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/kount/logo.htm?m=123456&s="+failed, nil))
+	collected, _ := NewSessionID()
+	status = http.StatusOK
+	proxy.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/kount/logo.htm?m=123456&s="+collected, nil))
+
+	if ok, _ := store.Collected(failed); ok {
+		t.Error("failed collection recorded")
+	}
+	if ok, _ := store.Collected(collected); !ok {
+		t.Error("successful collection not recorded")
+	}
+}
@@ -0,0 +1,72 @@
+// The datacollector package supports Kount's device data collector, which
+// gathers device information for a session before the inquiry is sent.
+package datacollector
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/http"
+)
+
+const (
+	SessionIDLength   = 32
+	DefaultCookieName = "kaxsdc_sess"
+	sessionAlphabet   = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+var ErrInvalidSessionID = errors.New("datacollector: session id must be 32 alphanumeric characters")
+
+// NewSessionID generates a random 32 character alphanumeric session id
+// suitable for both the data collector and the inquiry SESS field.
+func NewSessionID() (string, error) {
+	id := make([]byte, SessionIDLength)
+	buf := make([]byte, SessionIDLength)
+	n := 0
+	for n < SessionIDLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			// reject bytes that would bias the distribution
+			if int(b) >= 256-(256%len(sessionAlphabet)) {
+				continue
+			}
+			id[n] = sessionAlphabet[int(b)%len(sessionAlphabet)]
+			n++
+			if n == SessionIDLength {
+				break
+			}
+		}
+	}
+	return string(id), nil
+}
+
+// ValidSessionID reports whether id is a 32 character alphanumeric string.
+func ValidSessionID(id string) bool {
+	if len(id) != SessionIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// SessionFromRequest returns the session id stored in the named cookie of an
+// incoming HTTP request. An empty cookie name uses DefaultCookieName.
+func SessionFromRequest(r *http.Request, cookieName string) (string, error) {
+	if cookieName == "" {
+		cookieName = DefaultCookieName
+	}
+	cookie, err := r.Cookie(cookieName)
+	if err != nil {
+		return "", err
+	}
+	if !ValidSessionID(cookie.Value) {
+		return "", ErrInvalidSessionID
+	}
+	return cookie.Value, nil
+}
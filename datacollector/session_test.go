@@ -0,0 +1,59 @@
+package datacollector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewSessionID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id, err := NewSessionID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ValidSessionID(id) {
+			t.Fatalf("invalid session id %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("repeated session id %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestValidSessionID(t *testing.T) {
+	tests := map[string]bool{
+		"0123456789abcdefABCDEF0123456789":  true,
+		"0123456789abcdefABCDEF012345678":   false,
+		"0123456789abcdefABCDEF01234567890": false,
+		"0123456789abcdef-BCDEF0123456789":  false,
+		"":                                  false,
+	}
+	for id, want := range tests {
+		if got := ValidSessionID(id); got != want {
+			t.Errorf("ValidSessionID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestSessionFromRequest(t *testing.T) {
+	const id = "0123456789abcdefABCDEF0123456789"
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: DefaultCookieName, Value: id})
+	if got, err := SessionFromRequest(r, ""); err != nil || got != id {
+		t.Errorf("default cookie: got %q, %v", got, err)
+	}
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "sess", Value: "too-short"})
+	if _, err := SessionFromRequest(r, "sess"); err != ErrInvalidSessionID {
+		t.Errorf("invalid cookie: got %v", err)
+	}
+
+	if _, err := SessionFromRequest(httptest.NewRequest("GET", "/", nil), ""); err == nil {
+		t.Error("missing cookie: want an error")
+	}
+}
@@ -0,0 +1,85 @@
+package datacollector
+
+import (
+	"sync"
+	"time"
+)
+
+// Store records which sessions the data collector has seen.
+type Store interface {
+	// MarkCollected records a data collector hit for the session.
+	MarkCollected(sessionID string, at time.Time) error
+	// Collected reports whether the data collector has run for the session.
+	Collected(sessionID string) (bool, error)
+}
+
+// MemoryStore is an in-memory Store. Sessions expire after the session id's
+// 30 day lifetime.
+type MemoryStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	sessions  map[string]time.Time
+	lastPurge time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		ttl:      sessionMaxAge,
+		sessions: make(map[string]time.Time),
+	}
+}
+
+// Set how long a recorded session is kept.
+func (m *MemoryStore) SetTTL(ttl time.Duration) {
+	m.mu.Lock()
+	m.ttl = ttl
+	m.mu.Unlock()
+}
+
+func (m *MemoryStore) MarkCollected(sessionID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[sessionID] = at
+	// drop expired sessions at most once an hour
+	if at.Sub(m.lastPurge) > time.Hour {
+		for id, seen := range m.sessions {
+			if at.Sub(seen) > m.ttl {
+				delete(m.sessions, id)
+			}
+		}
+		m.lastPurge = at
+	}
+	return nil
+}
+
+func (m *MemoryStore) Collected(sessionID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen, ok := m.sessions[sessionID]
+	if !ok {
+		return false, nil
+	}
+	return time.Since(seen) <= m.ttl, nil
+}
+
+// WaitForCollection polls the store until the session has been collected or
+// the timeout elapses.
+func WaitForCollection(store Store, sessionID string, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		collected, err := store.Collected(sessionID)
+		if err != nil || collected {
+			return collected, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, nil
+		}
+		if remaining > pollInterval {
+			remaining = pollInterval
+		}
+		time.Sleep(remaining)
+	}
+}
+
+const pollInterval = 50 * time.Millisecond
@@ -0,0 +1,66 @@
+package datacollector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	if ok, err := store.Collected("a"); ok || err != nil {
+		t.Fatalf("unknown session: got %v, %v", ok, err)
+	}
+
+	store.MarkCollected("a", time.Now())
+	if ok, _ := store.Collected("a"); !ok {
+		t.Error("recorded session not collected")
+	}
+
+	store.SetTTL(time.Minute)
+	store.MarkCollected("old", time.Now().Add(-2*time.Minute))
+	if ok, _ := store.Collected("old"); ok {
+		t.Error("expired session still collected")
+	}
+}
+
+func TestMemoryStorePurgesExpiredSessions(t *testing.T) {
+	store := NewMemoryStore()
+	store.SetTTL(time.Minute)
+	now := time.Now()
+	store.MarkCollected("old", now.Add(-3*time.Hour))
+	store.MarkCollected("new", now)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.sessions["old"]; ok || len(store.sessions) != 1 {
+		t.Errorf("got sessions %v", store.sessions)
+	}
+}
+
+func TestWaitForCollection(t *testing.T) {
+	store := NewMemoryStore()
+
+	start := time.Now()
+	if ok, err := WaitForCollection(store, "a", 120*time.Millisecond); ok || err != nil {
+		t.Fatalf("never collected: got %v, %v", ok, err)
+	}
+	if elapsed := time.Since(start); elapsed < 120*time.Millisecond {
+		t.Errorf("returned after %s, before the timeout", elapsed)
+	}
+
+	go func() {
+		time.Sleep(60 * time.Millisecond)
+		store.MarkCollected("a", time.Now())
+	}()
+	start = time.Now()
+	if ok, err := WaitForCollection(store, "a", 5*time.Second); !ok || err != nil {
+		t.Fatalf("collected later: got %v, %v", ok, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s to notice the collection", elapsed)
+	}
+
+	if ok, _ := WaitForCollection(store, "a", 0); !ok {
+		t.Error("zero timeout: collected session not reported")
+	}
+}
@@ -0,0 +1,191 @@
+/**
+ * The kountcentral package supports gateways that screen transactions on
+ * behalf of many sub-merchants through Kount Central, e.g.
+ *
+ *	gateway := kountcentral.NewGateway(s)
+ *	gateway.Register(&kountcentral.Tenant{CustomerID: "shop-1", Mode: kountcentral.ModeThreshold})
+ *	inquiry, err := gateway.NewInquiry("shop-1")
+ *	...
+ *	resp, err := inquiry.GetResponse()
+ *	tenant, result, err := gateway.Route(resp)
+ */
+package kountcentral
+
+import (
+	"errors"
+	"fmt"
+	"github.com/phpsquid/kount/policy"
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/response"
+	"github.com/phpsquid/kount/settings"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrUnknownTenant   = errors.New("kountcentral: unknown customer id")
+	ErrDuplicateTenant = errors.New("kountcentral: customer id is already registered")
+	ErrInvalidTenant   = errors.New("kountcentral: invalid tenant")
+	ErrUnroutable      = errors.New("kountcentral: response has no customer id")
+)
+
+// actions of tenants without a policy, see DecisionAction
+const (
+	ActionApprove  policy.Action = "approve"
+	ActionReview   policy.Action = "review"
+	ActionDecline  policy.Action = "decline"
+	ActionEscalate policy.Action = "escalate"
+)
+
+/**
+ * DecisionAction maps the overall Kount Central decision to the action of a
+ * tenant without a policy: DecisionApprove to ActionApprove, DecisionReview
+ * to ActionReview, DecisionDecline to ActionDecline and DecisionEscalate to
+ * ActionEscalate. Any other decision is ActionReview, so a value the SDK does
+ * not know is looked at rather than approved.
+ */
+func DecisionAction(d response.Decision) policy.Action {
+	switch d {
+	case response.DecisionApprove:
+		return ActionApprove
+	case response.DecisionDecline:
+		return ActionDecline
+	case response.DecisionEscalate:
+		return ActionEscalate
+	}
+	return ActionReview
+}
+
+// Gateway sends inquiries for its tenants with the gateway's own settings.
+type Gateway struct {
+	settings *settings.Settings
+
+	mu       sync.RWMutex
+	tenants  map[string]*Tenant
+	unrouted int
+}
+
+func NewGateway(s *settings.Settings) *Gateway {
+	return &Gateway{settings: s, tenants: make(map[string]*Tenant)}
+}
+
+// Register a tenant. Its customer id must be set and unique, and its mode,
+// if set, must be ModeFull or ModeThreshold.
+func (g *Gateway) Register(t *Tenant) error {
+	if t.CustomerID == "" {
+		return fmt.Errorf("%w: customer id is required", ErrInvalidTenant)
+	}
+	if mode := t.mode(); mode != ModeFull && mode != ModeThreshold {
+		return fmt.Errorf("%w: customer %q: mode must be W or J, got %q", ErrInvalidTenant, t.CustomerID, mode)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.tenants[t.CustomerID]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateTenant, t.CustomerID)
+	}
+	g.tenants[t.CustomerID] = t
+	return nil
+}
+
+// Get the tenant with the given customer id.
+func (g *Gateway) Tenant(customerID string) (*Tenant, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	t, ok := g.tenants[customerID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTenant, customerID)
+	}
+	return t, nil
+}
+
+// Get the registered customer ids, sorted.
+func (g *Gateway) CustomerIDs() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	ids := make([]string, 0, len(g.tenants))
+	for id := range g.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+/**
+ * Create an inquiry for a tenant with its customer id, mode and defaults
+ * set. Setters called afterwards override the defaults.
+ */
+func (g *Gateway) NewInquiry(customerID string) (*request.Inquiry, error) {
+	t, err := g.Tenant(customerID)
+	if err != nil {
+		return nil, err
+	}
+	i := request.NewInquiry(g.settings)
+	for key, value := range t.Defaults {
+		i.SetParm(key, value)
+	}
+	i.SetMode(t.mode())
+	i.SetKCCustomerID(t.CustomerID)
+	t.countInquiry()
+	t.logger().Printf("inquiry created, mode %s", t.mode())
+	return i, nil
+}
+
+/**
+ * Route a response to the tenant named by its KC_CUSTOMER_ID and record it
+ * in the tenant's metrics. Responses without a customer id, or for an
+ * unregistered one, are counted as unrouted.
+ */
+func (g *Gateway) Route(resp *response.Response) (*Tenant, response.KountCentralResult, error) {
+	result := resp.KountCentral()
+	if result.CustomerID == "" {
+		g.countUnrouted()
+		return nil, result, ErrUnroutable
+	}
+	t, err := g.Tenant(result.CustomerID)
+	if err != nil {
+		g.countUnrouted()
+		return nil, result, err
+	}
+	t.countResponse(result)
+	t.logger().Printf("response routed: TRAN %s, KC decision %s, %d events, %d warnings, %d errors",
+		resp.GetTransactionId(), result.Decision(), len(result.Events), len(result.Warnings), len(result.Errors))
+	return t, result, nil
+}
+
+/**
+ * Route a response and evaluate it with the tenant's policy. Tenants without
+ * a policy get the DecisionAction of the overall Kount Central decision.
+ */
+func (g *Gateway) Evaluate(resp *response.Response, inquiry *request.Inquiry) (*Tenant, policy.Result, error) {
+	t, result, err := g.Route(resp)
+	if err != nil {
+		return nil, policy.Result{}, err
+	}
+	if t.Policy == nil {
+		decision := result.Decision()
+		return t, policy.Result{
+			Action:      DecisionAction(decision),
+			Explanation: fmt.Sprintf("no tenant policy: Kount Central decision %s", decision),
+		}, nil
+	}
+	outcome, err := t.Policy.Evaluate(resp, inquiry)
+	if err != nil {
+		return t, outcome, err
+	}
+	t.logger().Print(outcome.Explanation)
+	return t, outcome, nil
+}
+
+// Get the number of responses that could not be routed to a tenant.
+func (g *Gateway) Unrouted() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.unrouted
+}
+
+func (g *Gateway) countUnrouted() {
+	g.mu.Lock()
+	g.unrouted++
+	g.mu.Unlock()
+}
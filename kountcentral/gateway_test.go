@@ -0,0 +1,237 @@
+package kountcentral_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/phpsquid/kount/kountcentral"
+	"github.com/phpsquid/kount/policy"
+	"github.com/phpsquid/kount/response"
+	"github.com/phpsquid/kount/settings"
+)
+
+// a Kount Central threshold event answered for a customer id
+type event struct {
+	decision response.Decision
+	code     string
+}
+
+/**
+ * Serve a Kount Central endpoint that answers each request with the events
+ * scripted for its CUSTOMER_ID. A customer id of "unknown" is answered with
+ * another id, and "none" without one.
+ */
+func centralServer(t *testing.T, events map[string][]event) *settings.Settings {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fields := response.EchoFields(map[string]string{
+			"MODE": r.PostForm.Get("MODE"),
+			"MERC": r.PostForm.Get("MERC"),
+			"SESS": r.PostForm.Get("SESS"),
+		})
+		fields["AUTO"] = "A"
+		customerID := r.PostForm.Get("CUSTOMER_ID")
+		switch customerID {
+		case "none":
+		case "unknown":
+			fields["KC_CUSTOMER_ID"] = "not-registered"
+		default:
+			fields["KC_CUSTOMER_ID"] = customerID
+		}
+		scripted := events[customerID]
+		fields["KC_TRIGGERED_COUNT"] = strconv.Itoa(len(scripted))
+		for i, e := range scripted {
+			n := strconv.Itoa(i + 1)
+			fields["KC_EVENT_"+n+"_DECISION"] = string(e.decision)
+			fields["KC_EVENT_"+n+"_EXPRESSION"] = "SCOR>0"
+			fields["KC_EVENT_"+n+"_CODE"] = e.code
+		}
+		body, err := response.Encode(fields, response.FormatKeyValue)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return settings.New("123456", srv.URL, "key", "kountcentral-config")
+}
+
+// Send an inquiry for the customer and evaluate the response.
+func evaluate(t *testing.T, gateway *kountcentral.Gateway, customerID string) (*kountcentral.Tenant, policy.Result, error) {
+	t.Helper()
+	inquiry, err := gateway.NewInquiry(customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inquiry.SetSessionID("SESSION")
+	resp, err := inquiry.GetResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gateway.Evaluate(resp, inquiry)
+}
+
+func TestGatewayEvaluatesTriggeredEvents(t *testing.T) {
+	s := centralServer(t, map[string][]event{
+		"shop-1": {{response.DecisionReview, "review-score"}, {response.DecisionDecline, "decline-total"}},
+		"shop-2": {{response.DecisionReview, "review-score"}},
+	})
+	engine := policy.NewEngine("accept")
+	engine.Add("large orders", policy.KCEventTriggered("decline-total"), "hold")
+	engine.Add("any review", policy.KCDecision(response.DecisionReview), "queue")
+
+	gateway := kountcentral.NewGateway(s)
+	for _, id := range []string{"shop-1", "shop-2"} {
+		if err := gateway.Register(&kountcentral.Tenant{CustomerID: id, Mode: kountcentral.ModeThreshold, Policy: engine}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		customerID string
+		policy     string
+		action     policy.Action
+	}{
+		{"shop-1", "large orders", "hold"},
+		{"shop-2", "any review", "queue"},
+	}
+	for _, test := range tests {
+		tenant, result, err := evaluate(t, gateway, test.customerID)
+		if err != nil {
+			t.Fatalf("%s: %v", test.customerID, err)
+		}
+		if tenant.CustomerID != test.customerID || result.Policy != test.policy || result.Action != test.action {
+			t.Errorf("%s: got tenant %s, %+v", test.customerID, tenant.CustomerID, result)
+		}
+	}
+
+	tenant, err := gateway.Tenant("shop-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := tenant.Metrics(); m.Inquiries != 1 || m.Responses != 1 || m.Decisions[response.DecisionDecline] != 1 {
+		t.Errorf("shop-1 metrics %+v", m)
+	}
+}
+
+func TestGatewayUsesTheTenantPolicy(t *testing.T) {
+	s := centralServer(t, map[string][]event{
+		"strict":  {{response.DecisionReview, "review-score"}},
+		"lenient": {{response.DecisionReview, "review-score"}},
+	})
+	strict := policy.NewEngine("approve")
+	strict.Add("reviewed", policy.KCDecision(response.DecisionReview), "decline")
+	lenient := policy.NewEngine("approve")
+
+	gateway := kountcentral.NewGateway(s)
+	gateway.Register(&kountcentral.Tenant{CustomerID: "strict", Policy: strict})
+	gateway.Register(&kountcentral.Tenant{CustomerID: "lenient", Policy: lenient})
+
+	if _, result, err := evaluate(t, gateway, "strict"); err != nil || result.Action != "decline" || !result.Matched {
+		t.Errorf("strict: got %+v, %v", result, err)
+	}
+	if _, result, err := evaluate(t, gateway, "lenient"); err != nil || result.Action != "approve" || result.Matched {
+		t.Errorf("lenient: got %+v, %v", result, err)
+	}
+}
+
+func TestGatewayDefaultAction(t *testing.T) {
+	s := centralServer(t, map[string][]event{
+		"review":   {{response.DecisionApprove, "ok"}, {response.DecisionReview, "review-score"}},
+		"decline":  {{response.DecisionDecline, "decline-score"}},
+		"escalate": {{response.DecisionEscalate, "escalate-score"}},
+		"odd":      {{"X", "new-decision"}},
+	})
+	gateway := kountcentral.NewGateway(s)
+	for _, id := range []string{"quiet", "review", "decline", "escalate", "odd"} {
+		if err := gateway.Register(&kountcentral.Tenant{CustomerID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		customerID string
+		action     policy.Action
+	}{
+		{"quiet", kountcentral.ActionApprove},
+		{"review", kountcentral.ActionReview},
+		{"decline", kountcentral.ActionDecline},
+		{"escalate", kountcentral.ActionEscalate},
+		{"odd", kountcentral.ActionReview},
+	}
+	for _, test := range tests {
+		_, result, err := evaluate(t, gateway, test.customerID)
+		if err != nil {
+			t.Fatalf("%s: %v", test.customerID, err)
+		}
+		if result.Action != test.action || result.Matched {
+			t.Errorf("%s: got %+v, want %s", test.customerID, result, test.action)
+		}
+	}
+}
+
+func TestDecisionAction(t *testing.T) {
+	tests := []struct {
+		decision response.Decision
+		action   policy.Action
+	}{
+		{response.DecisionApprove, "approve"},
+		{response.DecisionReview, "review"},
+		{response.DecisionDecline, "decline"},
+		{response.DecisionEscalate, "escalate"},
+		{"", "review"},
+		{"X", "review"},
+	}
+	for _, test := range tests {
+		if got := kountcentral.DecisionAction(test.decision); got != test.action {
+			t.Errorf("%q: got %q, want %q", string(test.decision), got, test.action)
+		}
+	}
+}
+
+func TestGatewayCountsUnroutedResponses(t *testing.T) {
+	s := centralServer(t, nil)
+	gateway := kountcentral.NewGateway(s)
+	for _, id := range []string{"unknown", "none"} {
+		if err := gateway.Register(&kountcentral.Tenant{CustomerID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := evaluate(t, gateway, "unknown"); !errors.Is(err, kountcentral.ErrUnknownTenant) {
+		t.Errorf("unregistered customer id: got %v", err)
+	}
+	if _, _, err := evaluate(t, gateway, "none"); err != kountcentral.ErrUnroutable {
+		t.Errorf("no customer id: got %v", err)
+	}
+	if gateway.Unrouted() != 2 {
+		t.Errorf("unrouted %d, want 2", gateway.Unrouted())
+	}
+}
+
+func TestGatewayInquiryDefaults(t *testing.T) {
+	gateway := kountcentral.NewGateway(centralServer(t, nil))
+	if err := gateway.Register(&kountcentral.Tenant{CustomerID: "shop", Defaults: map[string]string{"SITE": "SHOP", "MODE": "Q"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gateway.Register(&kountcentral.Tenant{CustomerID: "bad", Mode: "Q"}); !errors.Is(err, kountcentral.ErrInvalidTenant) {
+		t.Errorf("mode Q: got %v", err)
+	}
+
+	inquiry, err := gateway.NewInquiry("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inquiry.GetParm("SITE") != "SHOP" || inquiry.GetParm("MODE") != kountcentral.ModeFull || inquiry.GetParm("CUSTOMER_ID") != "shop" {
+		t.Errorf("got SITE %q, MODE %q, CUSTOMER_ID %q", inquiry.GetParm("SITE"), inquiry.GetParm("MODE"), inquiry.GetParm("CUSTOMER_ID"))
+	}
+	if _, err := gateway.NewInquiry("missing"); !errors.Is(err, kountcentral.ErrUnknownTenant) {
+		t.Errorf("missing tenant: got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package kountcentral
+
+import (
+	"github.com/phpsquid/kount/policy"
+	"github.com/phpsquid/kount/response"
+	"io/ioutil"
+	"log"
+	"sync"
+)
+
+const (
+	// ModeFull runs a full RIS inquiry plus the customer's thresholds.
+	ModeFull = "W"
+	// ModeThreshold only evaluates the customer's thresholds.
+	ModeThreshold = "J"
+)
+
+/**
+ * Tenant is a sub-merchant screened through the gateway, identified by its
+ * Kount Central CUSTOMER_ID. Defaults are request parameters set on every
+ * inquiry for the tenant, e.g. SITE or UDFs. Policy, when set, turns the
+ * tenant's responses into the gateway's own action. Logger receives the
+ * tenant's log lines only; nil discards them.
+ */
+type Tenant struct {
+	CustomerID string
+	Mode       string
+	Defaults   map[string]string
+	Policy     *policy.Engine
+	Logger     *log.Logger
+
+	mu      sync.Mutex
+	metrics Metrics
+}
+
+// Metrics counts the traffic of one tenant.
+type Metrics struct {
+	Inquiries int
+	Responses int
+	Decisions map[response.Decision]int // overall Kount Central decisions
+	Warnings  int
+	Errors    int
+}
+
+// Get a snapshot of the tenant's metrics.
+func (t *Tenant) Metrics() Metrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.metrics
+	m.Decisions = make(map[response.Decision]int, len(t.metrics.Decisions))
+	for d, n := range t.metrics.Decisions {
+		m.Decisions[d] = n
+	}
+	return m
+}
+
+// Reset the tenant's metrics.
+func (t *Tenant) ResetMetrics() {
+	t.mu.Lock()
+	t.metrics = Metrics{}
+	t.mu.Unlock()
+}
+
+func (t *Tenant) mode() string {
+	if t.Mode == "" {
+		return ModeFull
+	}
+	return t.Mode
+}
+
+func (t *Tenant) countInquiry() {
+	t.mu.Lock()
+	t.metrics.Inquiries++
+	t.mu.Unlock()
+}
+
+func (t *Tenant) countResponse(result response.KountCentralResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics.Responses++
+	if t.metrics.Decisions == nil {
+		t.metrics.Decisions = make(map[response.Decision]int)
+	}
+	t.metrics.Decisions[result.Decision()]++
+	t.metrics.Warnings += len(result.Warnings)
+	t.metrics.Errors += len(result.Errors)
+}
+
+func (t *Tenant) logger() *log.Logger {
+	if t.Logger == nil {
+		return log.New(ioutil.Discard, "", 0)
+	}
+	return t.Logger
+}
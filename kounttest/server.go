@@ -0,0 +1,251 @@
+/**
+ * The kounttest package provides an in-process fake RIS endpoint for tests.
+ *
+ *	srv := kounttest.NewServer("123456", "test-api-key")
+ *	defer srv.Close()
+ *	srv.Enqueue(kounttest.Reply{Auto: response.DecisionDecline, Score: 87})
+ *
+ *	inquiry := request.NewInquiry(srv.Settings())
+ *	resp, err := inquiry.GetResponse()
+ *	// resp.GetAuto() == "D", srv.Requests()[0].Form.Get("SESS") == ...
+ */
+package kounttest
+
+import (
+	"github.com/phpsquid/kount/response"
+	"github.com/phpsquid/kount/settings"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const ConfigKey = "kounttest-config-key"
+
+// Reply scripts one RIS answer. The zero Reply approves with a score of 0.
+type Reply struct {
+	Auto     response.Decision // AUTO, DecisionApprove when empty
+	Score    int               // SCOR
+	Rules    []response.Rule   // RULE_ID_n and RULE_DESCRIPTION_n, Index is ignored
+	Counters []response.Counter
+	Warnings []string          // WARNING_n, e.g. "399 BAD_OPTN Field: [UDF], Value: [x]"
+	Errors   []string          // ERROR_n; MODE=E and ERRO are set from the first error
+	Fields   map[string]string // extra or overriding response fields
+
+	Status int           // HTTP status, 200 when zero; other statuses send no RIS body
+	Body   string        // raw body sent instead of the generated fields
+	Delay  time.Duration // wait before answering
+}
+
+// RecordedRequest is a request received by the fake endpoint.
+type RecordedRequest struct {
+	Method     string
+	Path       string
+	Header     http.Header
+	Form       url.Values
+	ReceivedAt time.Time
+}
+
+// Server is a fake RIS endpoint backed by httptest.Server.
+type Server struct {
+	*httptest.Server
+	merchantID string
+	apiKey     string
+
+	mu           sync.Mutex
+	queue        []Reply
+	defaultReply Reply
+	requests     []RecordedRequest
+	transactions int
+}
+
+// NewServer starts a fake RIS endpoint that accepts requests for merchantID
+// authenticated with apiKey. Close it when done.
+func NewServer(merchantID, apiKey string) *Server {
+	s := &Server{merchantID: merchantID, apiKey: apiKey}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Get settings pointing at the fake endpoint with its credentials.
+func (s *Server) Settings() *settings.Settings {
+	return settings.New(s.merchantID, s.URL, s.apiKey, ConfigKey)
+}
+
+// Enqueue replies answered in order, one per request. Once the queue is
+// empty the default reply is used.
+func (s *Server) Enqueue(replies ...Reply) {
+	s.mu.Lock()
+	s.queue = append(s.queue, replies...)
+	s.mu.Unlock()
+}
+
+// Set the reply used when no scripted reply is queued.
+func (s *Server) SetDefault(reply Reply) {
+	s.mu.Lock()
+	s.defaultReply = reply
+	s.mu.Unlock()
+}
+
+// Get every request received so far, in order.
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]RecordedRequest, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+// Get the most recent request. ok is false if none was received.
+func (s *Server) LastRequest() (req RecordedRequest, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return RecordedRequest{}, false
+	}
+	return s.requests[len(s.requests)-1], true
+}
+
+// Forget received requests and queued replies.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.queue = nil
+	s.requests = nil
+	s.mu.Unlock()
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, RecordedRequest{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Header:     r.Header.Clone(),
+		Form:       r.PostForm,
+		ReceivedAt: time.Now(),
+	})
+	reply := s.defaultReply
+	if len(s.queue) > 0 {
+		reply = s.queue[0]
+		s.queue = s.queue[1:]
+	}
+	s.transactions++
+	tran := "KT" + leftPad(strconv.Itoa(s.transactions), 10)
+	s.mu.Unlock()
+
+	if reply.Delay > 0 {
+		select {
+		case <-time.After(reply.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	format := r.PostForm.Get("FRMT")
+	if msg, ok := s.unauthorized(r); ok {
+		writeBody(w, http.StatusUnauthorized, response.ErrorFields(formFields(r.PostForm), "501 UNAUTH_REQ "+msg), format)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeBody(w, http.StatusOK, response.ErrorFields(formFields(r.PostForm), "261 MISSING_POST"), format)
+		return
+	}
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		w.WriteHeader(reply.Status)
+		w.Write([]byte(reply.Body))
+		return
+	}
+	if reply.Body != "" {
+		w.Write([]byte(reply.Body))
+		return
+	}
+	writeBody(w, http.StatusOK, reply.fields(r.PostForm, tran), format)
+}
+
+// Check the API key and merchant headers against the server's credentials.
+func (s *Server) unauthorized(r *http.Request) (string, bool) {
+	switch {
+	case r.Header.Get("X-Kount-Api-Key") != s.apiKey:
+		return "invalid API key", true
+	case r.Header.Get("X-Kount-Merc-Id") != s.merchantID:
+		return "invalid X-Kount-Merc-Id header", true
+	case r.PostForm.Get("MERC") != s.merchantID:
+		return "MERC does not match the API key", true
+	}
+	return "", false
+}
+
+// Build the response fields of a reply, echoing the request's identifiers.
+func (reply Reply) fields(form url.Values, tran string) map[string]string {
+	fields := response.EchoFields(formFields(form))
+	fields["TRAN"] = tran
+	if form.Get("TRAN") != "" {
+		fields["TRAN"] = form.Get("TRAN")
+	}
+	fields["AUTO"] = string(decisionOr(reply.Auto, response.DecisionApprove))
+	fields["SCOR"] = strconv.Itoa(reply.Score)
+
+	fields["RULES_TRIGGERED"] = strconv.Itoa(len(reply.Rules))
+	for i, rule := range reply.Rules {
+		fields["RULE_ID_"+strconv.Itoa(i)] = rule.ID
+		fields["RULE_DESCRIPTION_"+strconv.Itoa(i)] = rule.Description
+	}
+	fields["COUNTERS_TRIGGERED"] = strconv.Itoa(len(reply.Counters))
+	for i, counter := range reply.Counters {
+		fields["COUNTER_NAME_"+strconv.Itoa(i)] = counter.Name
+		fields["COUNTER_VALUE_"+strconv.Itoa(i)] = strconv.Itoa(counter.Value)
+	}
+	fields["WARNING_COUNT"] = strconv.Itoa(len(reply.Warnings))
+	for i, warning := range reply.Warnings {
+		fields["WARNING_"+strconv.Itoa(i)] = warning
+	}
+	if len(reply.Errors) > 0 {
+		for key, value := range response.ErrorFields(formFields(form), reply.Errors...) {
+			fields[key] = value
+		}
+	}
+	for key, value := range reply.Fields {
+		fields[key] = value
+	}
+	return fields
+}
+
+// decisionOr returns d, or fallback when d is empty.
+func decisionOr(d, fallback response.Decision) response.Decision {
+	if d == "" {
+		return fallback
+	}
+	return d
+}
+
+// Get the first value of each request field.
+func formFields(form url.Values) map[string]string {
+	fields := make(map[string]string, len(form))
+	for key := range form {
+		fields[key] = form.Get(key)
+	}
+	return fields
+}
+
+func writeBody(w http.ResponseWriter, status int, fields map[string]string, format string) {
+	body, err := response.Encode(fields, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func leftPad(s string, width int) string {
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package kounttest_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phpsquid/kount/kounttest"
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/response"
+	"github.com/phpsquid/kount/settings"
+)
+
+func TestGetResponseFormats(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+	srv.SetDefault(kounttest.Reply{
+		Auto:     response.DecisionReview,
+		Score:    42,
+		Rules:    []response.Rule{{ID: "10", Description: "Velocity > 2 = review"}, {ID: "20", Description: "Country <> US"}},
+		Counters: []response.Counter{{Name: "ORDERS", Value: 3}},
+		Warnings: []string{"399 BAD_OPTN Field: [UDF], Value: [x]"},
+		Fields: map[string]string{
+			"PREVIOUSLY_WHITELISTED": "Y",
+			"UAS":                    "Mozilla/5.0 (a=b) <&>",
+		},
+	})
+
+	for _, format := range []string{response.FormatKeyValue, response.FormatJSON, response.FormatXML} {
+		inquiry := request.NewInquiry(srv.Settings())
+		inquiry.SetSessionID("0123456789abcdef0123456789abcdef")
+		inquiry.SetFormat(format)
+
+		resp, err := inquiry.GetResponse()
+		if err != nil {
+			t.Fatalf("%q: %v", format, err)
+		}
+		if resp.Format != format {
+			t.Errorf("%q: response format %q", format, resp.Format)
+		}
+		if resp.GetDecision() != response.DecisionReview || resp.GetScore() != "42" {
+			t.Errorf("%q: AUTO=%s SCOR=%s", format, resp.GetAuto(), resp.GetScore())
+		}
+		wantRules := []response.Rule{
+			{Index: 0, ID: "10", Description: "Velocity > 2 = review"},
+			{Index: 1, ID: "20", Description: "Country <> US"},
+		}
+		if !reflect.DeepEqual(resp.Rules(), wantRules) {
+			t.Errorf("%q: rules %v", format, resp.Rules())
+		}
+		if counters, err := resp.Counters(); err != nil || len(counters) != 1 || counters[0].Value != 3 {
+			t.Errorf("%q: counters %v, %v", format, counters, err)
+		}
+		if warnings := resp.WarningDetails(); len(warnings) != 1 || warnings[0].Code != 399 || warnings[0].Field != "UDF" {
+			t.Errorf("%q: warnings %+v", format, warnings)
+		}
+		if resp.GetParam("PREVIOUSLY_WHITELISTED") != "Y" || resp.GetParam("UAS") != "Mozilla/5.0 (a=b) <&>" {
+			t.Errorf("%q: data %v", format, resp.Data)
+		}
+		if resp.GetParam("SESS") != "0123456789abcdef0123456789abcdef" {
+			t.Errorf("%q: SESS not echoed: %v", format, resp.Data)
+		}
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(requests))
+	}
+	last := requests[2]
+	if last.Header.Get("X-Kount-Api-Key") != "test-api-key" || last.Form.Get("MERC") != "123456" || last.Form.Get("FRMT") != response.FormatXML {
+		t.Errorf("last request: %v %v", last.Header, last.Form)
+	}
+}
+
+func TestGetResponseUnauthorized(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+
+	s := settings.New("123456", srv.URL, "wrong-key", kounttest.ConfigKey)
+	resp, err := request.NewInquiry(s).GetResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetMode() != "E" || resp.GetErrorCode() != "501" {
+		t.Errorf("got MODE=%s ERRO=%s", resp.GetMode(), resp.GetErrorCode())
+	}
+}
+
+func TestScriptedReplies(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+	srv.Enqueue(
+		kounttest.Reply{Auto: response.DecisionDecline, Score: 87},
+		kounttest.Reply{Errors: []string{"323 BAD_EMAL Cause: [Invalid email]"}},
+	)
+
+	first, err := request.NewInquiry(srv.Settings()).GetResponse()
+	if err != nil || first.GetDecision() != response.DecisionDecline {
+		t.Fatalf("first reply: %v, %v", first.GetAuto(), err)
+	}
+	second, err := request.NewInquiry(srv.Settings()).GetResponse()
+	if err != nil || second.GetMode() != "E" || second.GetErrorCode() != "323" {
+		t.Fatalf("second reply: MODE=%s ERRO=%s, %v", second.GetMode(), second.GetErrorCode(), err)
+	}
+	third, err := request.NewInquiry(srv.Settings()).GetResponse()
+	if err != nil || third.GetDecision() != response.DecisionApprove {
+		t.Fatalf("default reply: %v, %v", third.GetAuto(), err)
+	}
+}
@@ -0,0 +1,190 @@
+package normalize
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+var ErrInvalidEmail = errors.New("normalize: invalid email address")
+
+/**
+ * Email parses an RFC 5322 address, trims and lowercases it and converts an
+ * internationalized domain to its ASCII form, so "  John@Example.COM " and
+ * "john@example.com" are sent as the same identity. Only a bare address is
+ * accepted; display names, angle brackets and comments are rejected. A
+ * trailing dot on the domain ("john@example.com.") is dropped.
+ */
+func Email(address string) (string, error) {
+	address = strings.TrimSuffix(strings.TrimSpace(address), ".")
+	if !isBareAddress(address) {
+		return "", fmt.Errorf("%w: not a bare address", ErrInvalidEmail)
+	}
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+	if parsed.Name != "" {
+		return "", fmt.Errorf("%w: display names are not allowed", ErrInvalidEmail)
+	}
+
+	at := strings.LastIndex(parsed.Address, "@")
+	if at < 0 {
+		return "", fmt.Errorf("%w: missing domain", ErrInvalidEmail)
+	}
+	local := strings.ToLower(parsed.Address[:at])
+	domain, err := domainToASCII(strings.ToLower(parsed.Address[at+1:]))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+
+	if len(local) == 0 || len(local) > 64 {
+		return "", fmt.Errorf("%w: local part must be 1 to 64 characters", ErrInvalidEmail)
+	}
+	if err := checkDomain(domain); err != nil {
+		return "", err
+	}
+	if !isDotAtom(local) {
+		local = quoteLocal(local)
+	}
+	return local + "@" + domain, nil
+}
+
+/**
+ * CanonicalEmail normalizes an address and then applies provider specific
+ * rules, so aliases of the same mailbox collapse to one value: Gmail ignores
+ * dots and "+tags" in the local part, several other providers ignore "+tags".
+ * Addresses at other domains are only normalized.
+ */
+func CanonicalEmail(address string) (string, error) {
+	normalized, err := Email(address)
+	if err != nil {
+		return "", err
+	}
+	at := strings.LastIndex(normalized, "@")
+	local, domain := normalized[:at], normalized[at+1:]
+	if strings.HasPrefix(local, "\"") {
+		return normalized, nil
+	}
+
+	rule, ok := providerRules[domain]
+	if !ok {
+		return normalized, nil
+	}
+	if rule.plusTags {
+		if i := strings.Index(local, "+"); i > 0 {
+			local = local[:i]
+		}
+	}
+	if rule.ignoreDots {
+		local = strings.Replace(local, ".", "", -1)
+	}
+	if rule.domain != "" {
+		domain = rule.domain
+	}
+	return local + "@" + domain, nil
+}
+
+type providerRule struct {
+	plusTags   bool   // "+tag" suffixes are delivered to the base mailbox
+	ignoreDots bool   // dots in the local part are ignored
+	domain     string // canonical domain, if the provider has aliases
+}
+
+var providerRules = map[string]providerRule{
+	"gmail.com":      {plusTags: true, ignoreDots: true},
+	"googlemail.com": {plusTags: true, ignoreDots: true, domain: "gmail.com"},
+	"outlook.com":    {plusTags: true},
+	"hotmail.com":    {plusTags: true},
+	"live.com":       {plusTags: true},
+	"icloud.com":     {plusTags: true},
+	"me.com":         {plusTags: true, domain: "icloud.com"},
+	"mac.com":        {plusTags: true, domain: "icloud.com"},
+	"fastmail.com":   {plusTags: true},
+	"protonmail.com": {plusTags: true},
+	"proton.me":      {plusTags: true},
+}
+
+func checkDomain(domain string) error {
+	if len(domain) == 0 || len(domain) > 253 {
+		return fmt.Errorf("%w: domain must be 1 to 253 characters", ErrInvalidEmail)
+	}
+	// address literals such as [192.0.2.1] are left as they are
+	if strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]") {
+		return nil
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return fmt.Errorf("%w: domain must have at least two labels", ErrInvalidEmail)
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 {
+			return fmt.Errorf("%w: domain labels must be 1 to 63 characters", ErrInvalidEmail)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("%w: domain labels cannot start or end with a hyphen", ErrInvalidEmail)
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return fmt.Errorf("%w: invalid character in domain", ErrInvalidEmail)
+			}
+		}
+	}
+	return nil
+}
+
+// Report whether address is an addr-spec alone: outside quoted strings and
+// domain literals it may not contain whitespace or the characters of a
+// display name, angle address, comment or address list.
+func isBareAddress(address string) bool {
+	quoted, literal, escaped := false, false, false
+	for _, c := range address {
+		switch {
+		case escaped:
+			escaped = false
+		case (quoted || literal) && c == '\\':
+			escaped = true
+		case literal:
+			literal = c != ']'
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			literal = true
+		case c == ' ' || c == '\t' || strings.ContainsRune("<>(),;:", c):
+			return false
+		}
+	}
+	return !quoted && !literal
+}
+
+// Report whether the local part can be written without quotes.
+func isDotAtom(local string) bool {
+	if strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return false
+	}
+	for _, c := range local {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c > 127 {
+			continue
+		}
+		if !strings.ContainsRune("!#$%&'*+-/=?^_`{|}~.", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func quoteLocal(local string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range local {
+		if c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
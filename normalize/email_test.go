@@ -0,0 +1,81 @@
+package normalize
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEmail(t *testing.T) {
+	tests := []struct {
+		address, want string
+	}{
+		{"john@example.com", "john@example.com"},
+		{"  John@Example.COM ", "john@example.com"},
+		{"john@example.com.", "john@example.com"},
+		{"john.smith+shop@example.co.uk", "john.smith+shop@example.co.uk"},
+		{"jürgen@bücher.de", "jürgen@xn--bcher-kva.de"},
+		{`"john doe"@example.com`, `"john doe"@example.com`},
+		{`"john..doe"@example.com`, `"john..doe"@example.com`},
+		{`"john"@example.com`, "john@example.com"},
+		{"john@[192.0.2.1]", "john@[192.0.2.1]"},
+	}
+	for _, test := range tests {
+		got, err := Email(test.address)
+		if err != nil || got != test.want {
+			t.Errorf("Email(%q) = %q, %v; want %q", test.address, got, err, test.want)
+		}
+	}
+}
+
+func TestEmailRejectsInvalidAddresses(t *testing.T) {
+	for _, address := range []string{
+		"",
+		"john",
+		"john@",
+		"@example.com",
+		"john@localhost",
+		"john@-example.com",
+		"john@example..com",
+		"john@exa_mple.com",
+		"john@example.com..",
+		"John Smith <john@example.com>",
+		`"John Smith" <john@example.com>`,
+		"<john@example.com>",
+		"john@example.com (John Smith)",
+		"(work) john@example.com",
+		"john@example.com, jane@example.com",
+		"john@example.com; jane@example.com",
+		"john smith@example.com",
+		"john@example.com trailing",
+		`"unterminated@example.com`,
+		"john@[192.0.2.1",
+		"a12345678901234567890123456789012345678901234567890123456789012345@example.com",
+	} {
+		if got, err := Email(address); !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("Email(%q) = %q, %v; want ErrInvalidEmail", address, got, err)
+		}
+	}
+}
+
+func TestCanonicalEmail(t *testing.T) {
+	tests := []struct {
+		address, want string
+	}{
+		{"J.o.hn+shop@googlemail.com", "john@gmail.com"},
+		{"john.smith@gmail.com", "johnsmith@gmail.com"},
+		{"john.smith+news@outlook.com", "john.smith@outlook.com"},
+		{"john+news@me.com", "john@icloud.com"},
+		{"john.smith+news@example.com", "john.smith+news@example.com"},
+		{`"john+news"@gmail.com`, "john@gmail.com"},
+		{`"john doe+news"@gmail.com`, `"john doe+news"@gmail.com`},
+	}
+	for _, test := range tests {
+		got, err := CanonicalEmail(test.address)
+		if err != nil || got != test.want {
+			t.Errorf("CanonicalEmail(%q) = %q, %v; want %q", test.address, got, err, test.want)
+		}
+	}
+	if _, err := CanonicalEmail("John <john@gmail.com>"); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("CanonicalEmail accepted a display name: %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+// The normalize package cleans up customer supplied values, such as phone
+// numbers and email addresses, before they are sent to Kount.
+package normalize
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidPhone     = errors.New("normalize: invalid phone number")
+	ErrUnknownRegion    = errors.New("normalize: unknown phone region")
+	ErrUnknownCallCode  = errors.New("normalize: unknown country calling code")
+	ErrMissingPhoneCode = errors.New("normalize: phone number has no country calling code and no default region")
+)
+
+// The numbering plan of a country calling code.
+type numberPlan struct {
+	minLen int    // minimum length of the national significant number
+	maxLen int    // maximum length of the national significant number
+	trunk  string // national trunk prefix, dropped from national numbers
+	intl   string // international call prefix, besides "00"
+}
+
+var defaultPlan = numberPlan{minLen: 4, maxLen: 14, trunk: "0"}
+
+// numbering plans of the most common calling codes
+var numberPlans = map[string]numberPlan{
+	"1":   {10, 10, "1", "011"},
+	"7":   {10, 10, "8", "810"},
+	"20":  {8, 10, "0", ""},
+	"27":  {9, 9, "0", ""},
+	"30":  {10, 10, "", ""},
+	"31":  {9, 9, "0", ""},
+	"32":  {8, 9, "0", ""},
+	"33":  {9, 9, "0", ""},
+	"34":  {9, 9, "", ""},
+	"36":  {8, 9, "06", ""},
+	"39":  {6, 11, "", ""},
+	"40":  {9, 9, "0", ""},
+	"41":  {9, 9, "0", ""},
+	"43":  {4, 13, "0", ""},
+	"44":  {9, 10, "0", ""},
+	"45":  {8, 8, "", ""},
+	"46":  {7, 10, "0", ""},
+	"47":  {8, 8, "", ""},
+	"48":  {9, 9, "", ""},
+	"49":  {6, 13, "0", ""},
+	"51":  {8, 9, "0", ""},
+	"52":  {10, 10, "", ""},
+	"54":  {10, 11, "0", ""},
+	"55":  {10, 11, "0", ""},
+	"56":  {9, 9, "", ""},
+	"57":  {10, 10, "", ""},
+	"60":  {8, 10, "0", ""},
+	"61":  {9, 9, "0", "0011"},
+	"62":  {8, 12, "0", ""},
+	"63":  {8, 10, "0", ""},
+	"64":  {8, 10, "0", ""},
+	"65":  {8, 8, "", ""},
+	"66":  {8, 9, "0", ""},
+	"81":  {9, 10, "0", "010"},
+	"82":  {8, 10, "0", ""},
+	"84":  {9, 10, "0", ""},
+	"86":  {7, 12, "0", ""},
+	"90":  {10, 10, "0", ""},
+	"91":  {10, 10, "0", ""},
+	"351": {9, 9, "", ""},
+	"352": {4, 11, "", ""},
+	"353": {7, 9, "0", ""},
+	"358": {5, 12, "0", ""},
+	"420": {9, 9, "", ""},
+	"852": {8, 8, "", "001"},
+	"886": {8, 9, "0", ""},
+	"971": {8, 9, "0", ""},
+	"972": {8, 9, "0", ""},
+}
+
+/**
+ * Phone converts a phone number to E.164 format, e.g. "(555) 123-4567" with
+ * region "US" becomes "+15551234567". Numbers that start with "+" or an
+ * international call prefix keep their own country calling code; all other
+ * numbers are treated as national numbers of region, an ISO 3166-1 alpha-2
+ * country code. Extensions ("ext", "x", "#") are dropped.
+ */
+func Phone(number, region string) (string, error) {
+	digits, international, err := phoneDigits(number)
+	if err != nil {
+		return "", err
+	}
+
+	var code, national string
+	if international {
+		code, national, err = splitCallingCode(digits)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		regionCode := ""
+		if region != "" {
+			var ok bool
+			regionCode, ok = callingCodes[strings.ToUpper(strings.TrimSpace(region))]
+			if !ok {
+				return "", fmt.Errorf("%w %q", ErrUnknownRegion, region)
+			}
+		}
+		plan := planFor(regionCode)
+		switch {
+		case plan.intl != "" && strings.HasPrefix(digits, plan.intl):
+			code, national, err = splitCallingCode(digits[len(plan.intl):])
+		case strings.HasPrefix(digits, "00"):
+			code, national, err = splitCallingCode(digits[2:])
+		case regionCode == "":
+			return "", ErrMissingPhoneCode
+		default:
+			code, national = regionCode, digits
+			if plan.trunk != "" && strings.HasPrefix(national, plan.trunk) &&
+				len(national)-len(plan.trunk) >= plan.minLen {
+				national = national[len(plan.trunk):]
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	plan := planFor(code)
+	// drop a written trunk prefix such as "+44 (0)20 ..."
+	if international && plan.trunk == "0" && strings.HasPrefix(national, "0") {
+		national = national[1:]
+	}
+	if err := checkNational(code, national, plan); err != nil {
+		return "", err
+	}
+	return "+" + code + national, nil
+}
+
+// Extract the digits of a phone number and whether it started with "+".
+func phoneDigits(number string) (string, bool, error) {
+	number = strings.TrimSpace(number)
+	lower := strings.ToLower(number)
+	for _, marker := range []string{"ext", "x", "#"} {
+		if i := strings.Index(lower, marker); i > 0 {
+			number = number[:i]
+			lower = lower[:i]
+		}
+	}
+
+	var b strings.Builder
+	international := false
+	for _, c := range number {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == '+' && b.Len() == 0 && !international:
+			international = true
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')' || c == '/':
+		default:
+			return "", false, fmt.Errorf("%w: unexpected character %q", ErrInvalidPhone, c)
+		}
+	}
+	if b.Len() == 0 {
+		return "", false, fmt.Errorf("%w: no digits", ErrInvalidPhone)
+	}
+	return b.String(), international, nil
+}
+
+// Split a country calling code off an international number. Calling codes
+// are prefix free, so the shortest known match is the right one.
+func splitCallingCode(digits string) (string, string, error) {
+	for n := 1; n <= 3 && n < len(digits); n++ {
+		if knownCallingCodes[digits[:n]] {
+			return digits[:n], digits[n:], nil
+		}
+	}
+	return "", "", ErrUnknownCallCode
+}
+
+func planFor(code string) numberPlan {
+	if plan, ok := numberPlans[code]; ok {
+		return plan
+	}
+	return defaultPlan
+}
+
+func checkNational(code, national string, plan numberPlan) error {
+	switch {
+	case len(national) < plan.minLen:
+		return fmt.Errorf("%w: too short for +%s", ErrInvalidPhone, code)
+	case len(national) > plan.maxLen, len(code)+len(national) > 15:
+		return fmt.Errorf("%w: too long for +%s", ErrInvalidPhone, code)
+	}
+	// North American area codes cannot start with 0 or 1
+	if code == "1" && national[0] < '2' {
+		return fmt.Errorf("%w: not a valid North American number", ErrInvalidPhone)
+	}
+	return nil
+}
+
+var knownCallingCodes = func() map[string]bool {
+	codes := make(map[string]bool)
+	for _, code := range callingCodes {
+		codes[code] = true
+	}
+	return codes
+}()
+
+// country calling codes by ISO 3166-1 alpha-2 region
+var callingCodes = map[string]string{
+	"AD": "376", "AE": "971", "AF": "93", "AG": "1", "AI": "1", "AL": "355",
+	"AM": "374", "AO": "244", "AR": "54", "AS": "1", "AT": "43", "AU": "61",
+	"AW": "297", "AX": "358", "AZ": "994", "BA": "387", "BB": "1", "BD": "880",
+	"BE": "32", "BF": "226", "BG": "359", "BH": "973", "BI": "257", "BJ": "229",
+	"BL": "590", "BM": "1", "BN": "673", "BO": "591", "BQ": "599", "BR": "55",
+	"BS": "1", "BT": "975", "BW": "267", "BY": "375", "BZ": "501", "CA": "1",
+	"CC": "61", "CD": "243", "CF": "236", "CG": "242", "CH": "41", "CI": "225",
+	"CK": "682", "CL": "56", "CM": "237", "CN": "86", "CO": "57", "CR": "506",
+	"CU": "53", "CV": "238", "CW": "599", "CX": "61", "CY": "357", "CZ": "420",
+	"DE": "49", "DJ": "253", "DK": "45", "DM": "1", "DO": "1", "DZ": "213",
+	"EC": "593", "EE": "372", "EG": "20", "EH": "212", "ER": "291", "ES": "34",
+	"ET": "251", "FI": "358", "FJ": "679", "FK": "500", "FM": "691", "FO": "298",
+	"FR": "33", "GA": "241", "GB": "44", "GD": "1", "GE": "995", "GF": "594",
+	"GG": "44", "GH": "233", "GI": "350", "GL": "299", "GM": "220", "GN": "224",
+	"GP": "590", "GQ": "240", "GR": "30", "GT": "502", "GU": "1", "GW": "245",
+	"GY": "592", "HK": "852", "HN": "504", "HR": "385", "HT": "509", "HU": "36",
+	"ID": "62", "IE": "353", "IL": "972", "IM": "44", "IN": "91", "IO": "246",
+	"IQ": "964", "IR": "98", "IS": "354", "IT": "39", "JE": "44", "JM": "1",
+	"JO": "962", "JP": "81", "KE": "254", "KG": "996", "KH": "855", "KI": "686",
+	"KM": "269", "KN": "1", "KP": "850", "KR": "82", "KW": "965", "KY": "1",
+	"KZ": "7", "LA": "856", "LB": "961", "LC": "1", "LI": "423", "LK": "94",
+	"LR": "231", "LS": "266", "LT": "370", "LU": "352", "LV": "371", "LY": "218",
+	"MA": "212", "MC": "377", "MD": "373", "ME": "382", "MF": "590", "MG": "261",
+	"MH": "692", "MK": "389", "ML": "223", "MM": "95", "MN": "976", "MO": "853",
+	"MP": "1", "MQ": "596", "MR": "222", "MS": "1", "MT": "356", "MU": "230",
+	"MV": "960", "MW": "265", "MX": "52", "MY": "60", "MZ": "258", "NA": "264",
+	"NC": "687", "NE": "227", "NF": "672", "NG": "234", "NI": "505", "NL": "31",
+	"NO": "47", "NP": "977", "NR": "674", "NU": "683", "NZ": "64", "OM": "968",
+	"PA": "507", "PE": "51", "PF": "689", "PG": "675", "PH": "63", "PK": "92",
+	"PL": "48", "PM": "508", "PR": "1", "PS": "970", "PT": "351", "PW": "680",
+	"PY": "595", "QA": "974", "RE": "262", "RO": "40", "RS": "381", "RU": "7",
+	"RW": "250", "SA": "966", "SB": "677", "SC": "248", "SD": "249", "SE": "46",
+	"SG": "65", "SH": "290", "SI": "386", "SJ": "47", "SK": "421", "SL": "232",
+	"SM": "378", "SN": "221", "SO": "252", "SR": "597", "SS": "211", "ST": "239",
+	"SV": "503", "SX": "1", "SY": "963", "SZ": "268", "TC": "1", "TD": "235",
+	"TG": "228", "TH": "66", "TJ": "992", "TK": "690", "TL": "670", "TM": "993",
+	"TN": "216", "TO": "676", "TR": "90", "TT": "1", "TV": "688", "TW": "886",
+	"TZ": "255", "UA": "380", "UG": "256", "US": "1", "UY": "598", "UZ": "998",
+	"VA": "39", "VC": "1", "VE": "58", "VG": "1", "VI": "1", "VN": "84",
+	"VU": "678", "WF": "681", "WS": "685", "XK": "383", "YE": "967", "YT": "262",
+	"ZA": "27", "ZM": "260", "ZW": "263",
+}
@@ -0,0 +1,67 @@
+package normalize
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPhone(t *testing.T) {
+	tests := []struct {
+		number, region, want string
+	}{
+		// national numbers in their region
+		{"(555) 123-4567", "US", "+15551234567"},
+		{"1-555-123-4567", "US", "+15551234567"},
+		{"555.123.4567 ext. 89", "us", "+15551234567"},
+		{"020 7946 0958", "GB", "+442079460958"},
+		{"030 123456789", "DE", "+4930123456789"},
+		{"06 12 34 56 78", "FR", "+33612345678"},
+		{"02 9374 4000", "AU", "+61293744000"},
+		{"03-1234-5678", "JP", "+81312345678"},
+		{"612 345 678", "ES", "+34612345678"},
+		{"06 1234 5678", "HU", "+3612345678"},
+		{"8 (495) 123-45-67", "RU", "+74951234567"},
+		// E.164 and other international forms keep their own code
+		{"+44 20 7946 0958", "US", "+442079460958"},
+		{"+44 (0)20 7946 0958", "", "+442079460958"},
+		{"+1 555 123 4567", "", "+15551234567"},
+		{"0044 20 7946 0958", "US", "+442079460958"},
+		{"011 44 20 7946 0958", "US", "+442079460958"},
+		{"0011 44 20 7946 0958", "AU", "+442079460958"},
+		{"+35312345678", "IE", "+35312345678"},
+		{"+8613812345678", "CN", "+8613812345678"},
+	}
+	for _, test := range tests {
+		got, err := Phone(test.number, test.region)
+		if err != nil || got != test.want {
+			t.Errorf("Phone(%q, %q) = %q, %v; want %q", test.number, test.region, got, err, test.want)
+		}
+	}
+}
+
+func TestPhoneErrors(t *testing.T) {
+	tests := []struct {
+		number, region string
+		want           error
+	}{
+		{"", "US", ErrInvalidPhone},
+		{"call me", "US", ErrInvalidPhone},
+		{"555-12a-4567", "US", ErrInvalidPhone},
+		{"123-4567", "US", ErrInvalidPhone},          // too short
+		{"555 123 45678", "US", ErrInvalidPhone},     // too long
+		{"(055) 123-4567", "US", ErrInvalidPhone},    // area code starting with 0
+		{"+1 155 123 4567", "", ErrInvalidPhone},     // area code starting with 1
+		{"+44 20 7946", "", ErrInvalidPhone},         // too short for +44
+		{"+1234567890123456", "", ErrInvalidPhone},   // over 15 digits
+		{"+999 1234 5678", "", ErrUnknownCallCode},   // unassigned calling code
+		{"020 7946 0958", "", ErrMissingPhoneCode},   // national without a region
+		{"020 7946 0958", "XX", ErrUnknownRegion},    // unknown region
+		{"+44 20 7946 0958 +1", "", ErrInvalidPhone}, // second plus sign
+	}
+	for _, test := range tests {
+		got, err := Phone(test.number, test.region)
+		if !errors.Is(err, test.want) {
+			t.Errorf("Phone(%q, %q) = %q, %v; want %v", test.number, test.region, got, err, test.want)
+		}
+	}
+}
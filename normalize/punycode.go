@@ -0,0 +1,132 @@
+package normalize
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// punycode parameters from RFC 3492
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+var errPunycodeOverflow = errors.New("normalize: punycode overflow")
+
+/**
+ * Convert a domain name to its ASCII form. Labels containing non-ASCII
+ * characters are lowercased and punycode encoded with the "xn--" prefix,
+ * e.g. "bücher.de" becomes "xn--bcher-kva.de".
+ */
+func domainToASCII(domain string) (string, error) {
+	labels := strings.Split(domain, ".")
+	for i, label := range labels {
+		if !isASCII(label) {
+			encoded, err := punycodeEncode(strings.ToLower(label))
+			if err != nil {
+				return "", err
+			}
+			labels[i] = "xn--" + encoded
+		}
+	}
+	return strings.Join(labels, "."), nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func punycodeEncode(label string) (string, error) {
+	input := []rune(label)
+	var out strings.Builder
+
+	for _, c := range input {
+		if c < utf8.RuneSelf {
+			out.WriteRune(c)
+		}
+	}
+	basic := out.Len()
+	handled := basic
+	if basic > 0 {
+		out.WriteByte('-')
+	}
+
+	n, delta, bias := rune(punyInitialN), 0, punyInitialBias
+	for handled < len(input) {
+		// the smallest code point not yet handled
+		m := rune(utf8.MaxRune)
+		for _, c := range input {
+			if c >= n && c < m {
+				m = c
+			}
+		}
+		if int(m-n) > (1<<31-1-delta)/(handled+1) {
+			return "", errPunycodeOverflow
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+
+		for _, c := range input {
+			if c < n {
+				delta++
+			}
+			if c != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+				if t < punyTMin {
+					t = punyTMin
+				} else if t > punyTMax {
+					t = punyTMax
+				}
+				if q < t {
+					break
+				}
+				out.WriteByte(punyDigit(t + (q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out.WriteByte(punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return out.String(), nil
+}
+
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func punyAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
@@ -0,0 +1,181 @@
+package normalize
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// The sample strings of RFC 3492 section 7.1, with the case annotations of
+// the encoded forms dropped since the encoder only emits lowercase digits.
+var punycodeSamples = []struct {
+	name, decoded, encoded string
+}{
+	{"A Arabic (Egyptian)",
+		"ليهمابتكل" +
+			"موشعربي؟",
+		"egbpdaj6bu4bxfgehfvwxn"},
+	{"B Chinese (simplified)",
+		"他们为什么不说中文",
+		"ihqwcrb4cv8a8dqg056pqjye"},
+	{"C Chinese (traditional)",
+		"他們爲什麽不說中文",
+		"ihqwctvzc91f659drss3x8bo0yb"},
+	{"D Czech",
+		"Pročprostěnemluvíčesky",
+		"Proprostnemluvesky-uyb24dma41a"},
+	{"E Hebrew",
+		"למההםפשוט" +
+			"לאמדבריםע" +
+			"ברית",
+		"4dbcagdahymbxekheh6e0a7fei0b"},
+	{"F Hindi (Devanagari)",
+		"यहलोगहिन्" +
+			"दीक्योंनह" +
+			"ींबोलसकते" +
+			"हैं",
+		"i1baa7eci9glrd9b2ae1bj0hfcgg6iyaf8o0a1dig0cd"},
+	{"G Japanese (kanji and hiragana)",
+		"なぜみんな日本語を" +
+			"話してくれないのか",
+		"n8jok5ay5dzabd5bym9f0cm5685rrjetr6pdxa"},
+	{"H Korean (Hangul syllables)",
+		"세계의모든사람들이" +
+			"한국어를이해한다면" +
+			"얼마나좋을까",
+		"989aomsvi5e83db1d2a355cv1e0vak1dwrv93d5xbh15a0dt30a5jpsd879ccm6fea98c"},
+	{"I Russian (Cyrillic)",
+		"почемужео" +
+			"нинеговор" +
+			"ятпорусск" +
+			"и",
+		"b1abfaaepdrnnbgefbadotcwatmq2g4l"},
+	{"J Spanish",
+		"PorquénopuedensimplementehablarenEspañol",
+		"PorqunopuedensimplementehablarenEspaol-fmd56a"},
+	{"K Vietnamese",
+		"TạisaohọkhôngthểchỉnóitiếngViệt",
+		"TisaohkhngthchnitingVit-kjcr8268qyxafd2f1b9g"},
+	{"L 3<nen>B<gumi><kinpachi><sensei>",
+		"3年B組金八先生",
+		"3B-ww4c5e180e575a65lsy2b"},
+	{"M <amuro><namie>-with-SUPER-MONKEYS",
+		"安室奈美恵-with-SUPER-MONKEYS",
+		"-with-SUPER-MONKEYS-pc58ag80a8qai00g7n9n"},
+	{"N Hello-Another-Way-<sorezore><no><basho>",
+		"Hello-Another-Way-それぞれの場所",
+		"Hello-Another-Way--fc4qua05auwb3674vfr0b"},
+	{"O <hitotsu><yane><no><shita>2",
+		"ひとつ屋根の下2",
+		"2-u9tlzr9756bt3uc0v"},
+	{"P Maji<de>Koi<suru>5<byou><mae>",
+		"MajiでKoiする5秒前",
+		"MajiKoi5-783gue6qz075azm5e"},
+	{"Q <pafii>de<runba>",
+		"パフィーdeルンバ",
+		"de-jg4avhby1noc0d"},
+	{"R <sono><supiido><de>",
+		"そのスピードで",
+		"d9juau41awczczp"},
+	{"S -> $1.00 <-",
+		"-> $1.00 <-",
+		"-> $1.00 <--"},
+}
+
+func TestPunycodeEncodeSamples(t *testing.T) {
+	for _, sample := range punycodeSamples {
+		got, err := punycodeEncode(sample.decoded)
+		if err != nil || got != sample.encoded {
+			t.Errorf("%s: punycodeEncode = %q, %v; want %q", sample.name, got, err, sample.encoded)
+		}
+	}
+}
+
+func TestPunycodeRoundTrip(t *testing.T) {
+	labels := []string{"bücher", "münchen", "ü", "üý", "a", "-", "日本語", "ελληνικά", "😀smile"}
+	for _, sample := range punycodeSamples {
+		labels = append(labels, sample.decoded)
+	}
+	for _, label := range labels {
+		encoded, err := punycodeEncode(label)
+		if err != nil {
+			t.Errorf("punycodeEncode(%q): %v", label, err)
+			continue
+		}
+		if decoded := punycodeDecode(t, encoded); decoded != label {
+			t.Errorf("punycodeEncode(%q) = %q, which decodes to %q", label, encoded, decoded)
+		}
+	}
+}
+
+func TestDomainToASCII(t *testing.T) {
+	tests := []struct {
+		domain, want string
+	}{
+		{"example.com", "example.com"},
+		{"bücher.de", "xn--bcher-kva.de"},
+		{"Bücher.de", "xn--bcher-kva.de"},
+		{"mail.例え.jp", "mail.xn--r8jz45g.jp"},
+	}
+	for _, test := range tests {
+		got, err := domainToASCII(test.domain)
+		if err != nil || got != test.want {
+			t.Errorf("domainToASCII(%q) = %q, %v; want %q", test.domain, got, err, test.want)
+		}
+	}
+}
+
+// Decode a punycode label, following the decoding procedure of RFC 3492
+// section 6.2, so encoded labels can be checked against their input.
+func punycodeDecode(t *testing.T, encoded string) string {
+	t.Helper()
+	var output []rune
+	basic := strings.LastIndex(encoded, "-")
+	if basic > 0 {
+		output = []rune(encoded[:basic])
+	}
+	rest := encoded[basic+1:]
+
+	n, i, bias := rune(punyInitialN), 0, punyInitialBias
+	for len(rest) > 0 {
+		oldi, w := i, 1
+		for k := punyBase; ; k += punyBase {
+			if len(rest) == 0 {
+				t.Fatalf("punycode %q: truncated", encoded)
+			}
+			c := rest[0]
+			rest = rest[1:]
+			var digit int
+			switch {
+			case c >= 'a' && c <= 'z':
+				digit = int(c - 'a')
+			case c >= 'A' && c <= 'Z':
+				digit = int(c - 'A')
+			case c >= '0' && c <= '9':
+				digit = int(c-'0') + 26
+			default:
+				t.Fatalf("punycode %q: bad digit %q", encoded, c)
+			}
+			i += digit * w
+			threshold := k - bias
+			if threshold < punyTMin {
+				threshold = punyTMin
+			} else if threshold > punyTMax {
+				threshold = punyTMax
+			}
+			if digit < threshold {
+				break
+			}
+			w *= punyBase - threshold
+		}
+		bias = punyAdapt(i-oldi, len(output)+1, oldi == 0)
+		n += rune(i / (len(output) + 1))
+		i %= len(output) + 1
+		if n > utf8.MaxRune {
+			t.Fatalf("punycode %q: code point out of range", encoded)
+		}
+		output = append(output[:i], append([]rune{n}, output[i:]...)...)
+		i++
+	}
+	return string(output)
+}
@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"fmt"
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/response"
+	"strconv"
+	"strings"
+)
+
+// Input is what a policy is evaluated against. Inquiry may be nil, in which
+// case conditions on inquiry fields do not match.
+type Input struct {
+	Response *response.Response
+	Inquiry  *request.Inquiry
+}
+
+// Condition is a named predicate on an Input.
+type Condition struct {
+	description string
+	match       func(in Input) bool
+}
+
+// NewCondition creates a custom condition. The description is used in
+// explanations, e.g. "customer is on the allow list".
+func NewCondition(description string, match func(in Input) bool) Condition {
+	return Condition{description: description, match: match}
+}
+
+// Match reports whether the condition holds for in.
+func (c Condition) Match(in Input) bool {
+	return c.match != nil && c.match(in)
+}
+
+func (c Condition) String() string {
+	return c.description
+}
+
+// Auto matches when the RIS auto decision is d.
+func Auto(d response.Decision) Condition {
+	return NewCondition("AUTO="+string(d), func(in Input) bool {
+		return in.Response.GetDecision() == d
+	})
+}
+
+// ScoreBelow matches when the Kount score is below score.
+func ScoreBelow(score int) Condition {
+	return NewCondition(fmt.Sprintf("SCOR<%d", score), func(in Input) bool {
+		n, err := strconv.Atoi(in.Response.GetScore())
+		return err == nil && n < score
+	})
+}
+
+// ScoreAtLeast matches when the Kount score is score or higher.
+func ScoreAtLeast(score int) Condition {
+	return NewCondition(fmt.Sprintf("SCOR>=%d", score), func(in Input) bool {
+		n, err := strconv.Atoi(in.Response.GetScore())
+		return err == nil && n >= score
+	})
+}
+
+// OmniscoreBelow matches when the Kount Omniscore is below score.
+func OmniscoreBelow(score float64) Condition {
+	return NewCondition(fmt.Sprintf("OMNISCORE<%g", score), func(in Input) bool {
+		f, err := strconv.ParseFloat(in.Response.GetOmniscore(), 64)
+		return err == nil && f < score
+	})
+}
+
+// TotalBelow matches when the inquiry total (TOTL, in pennies) is below total.
+func TotalBelow(total int64) Condition {
+	return NewCondition(fmt.Sprintf("TOTL<%d", total), func(in Input) bool {
+		if in.Inquiry == nil {
+			return false
+		}
+		n, err := strconv.ParseInt(in.Inquiry.GetParm("TOTL"), 10, 64)
+		return err == nil && n < total
+	})
+}
+
+// RuleTriggered matches when the rule with the given id was triggered.
+func RuleTriggered(ruleID string) Condition {
+	return NewCondition("rule "+ruleID+" triggered", func(in Input) bool {
+		return in.Response.HasRule(ruleID)
+	})
+}
+
+// KCDecision matches when the overall Kount Central decision is d.
+func KCDecision(d response.Decision) Condition {
+	return NewCondition("KC decision="+string(d), func(in Input) bool {
+		return in.Response.KountCentral().Decision() == d
+	})
+}
+
+// KCEventTriggered matches when a Kount Central threshold event with the
+// given code was triggered.
+func KCEventTriggered(code string) Condition {
+	return NewCondition("KC event "+code+" triggered", func(in Input) bool {
+		for _, event := range in.Response.KountCentral().Events {
+			if event.Code == code {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// And matches when all conditions match.
+func And(conditions ...Condition) Condition {
+	return NewCondition(join(conditions, " and "), func(in Input) bool {
+		for _, c := range conditions {
+			if !c.Match(in) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or matches when any condition matches.
+func Or(conditions ...Condition) Condition {
+	return NewCondition("("+join(conditions, " or ")+")", func(in Input) bool {
+		for _, c := range conditions {
+			if c.Match(in) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not matches when c does not.
+func Not(c Condition) Condition {
+	return NewCondition("not ("+c.String()+")", func(in Input) bool {
+		return !c.Match(in)
+	})
+}
+
+func join(conditions []Condition, sep string) string {
+	descriptions := make([]string, len(conditions))
+	for i, c := range conditions {
+		descriptions[i] = c.String()
+	}
+	return strings.Join(descriptions, sep)
+}
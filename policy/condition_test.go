@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/response"
+	"github.com/phpsquid/kount/settings"
+)
+
+func testInput(data map[string]string, total string) Input {
+	in := Input{Response: &response.Response{Data: data}}
+	if total != "" {
+		in.Inquiry = request.NewInquiry(settings.New("123456", "https://risk.test.kount.net", "key", "config"))
+		in.Inquiry.SetTotal(total)
+	}
+	return in
+}
+
+func TestConditions(t *testing.T) {
+	in := testInput(map[string]string{
+		"AUTO":                  "R",
+		"SCOR":                  "42",
+		"OMNISCORE":             "61.5",
+		"RULES_TRIGGERED":       "1",
+		"RULE_ID_0":             "1024",
+		"RULE_DESCRIPTION_0":    "Review if score over 30",
+		"KC_TRIGGERED_COUNT":    "1",
+		"KC_EVENT_1_DECISION":   "D",
+		"KC_EVENT_1_EXPRESSION": "SCOR>40",
+		"KC_EVENT_1_CODE":       "decline-score",
+	}, "4999")
+
+	tests := []struct {
+		condition Condition
+		want      bool
+	}{
+		{Auto(response.DecisionReview), true},
+		{Auto(response.DecisionApprove), false},
+		{ScoreBelow(43), true},
+		{ScoreBelow(42), false},
+		{ScoreAtLeast(42), true},
+		{ScoreAtLeast(43), false},
+		{OmniscoreBelow(62), true},
+		{OmniscoreBelow(61.5), false},
+		{TotalBelow(5000), true},
+		{TotalBelow(4999), false},
+		{RuleTriggered("1024"), true},
+		{RuleTriggered("2048"), false},
+		{KCDecision(response.DecisionDecline), true},
+		{KCDecision(response.DecisionApprove), false},
+		{KCEventTriggered("decline-score"), true},
+		{KCEventTriggered("review-total"), false},
+		{And(Auto(response.DecisionReview), ScoreBelow(50)), true},
+		{And(Auto(response.DecisionReview), ScoreBelow(10)), false},
+		{And(), true},
+		{Or(Auto(response.DecisionApprove), ScoreAtLeast(40)), true},
+		{Or(Auto(response.DecisionApprove), ScoreAtLeast(90)), false},
+		{Or(), false},
+		{Not(Auto(response.DecisionApprove)), true},
+		{Not(Auto(response.DecisionReview)), false},
+		{NewCondition("always", func(Input) bool { return true }), true},
+		{Condition{}, false},
+	}
+	for _, test := range tests {
+		if got := test.condition.Match(in); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.condition, got, test.want)
+		}
+	}
+}
+
+func TestConditionsWithMissingValues(t *testing.T) {
+	in := testInput(map[string]string{"SCOR": "high"}, "")
+	for _, c := range []Condition{
+		ScoreBelow(100),
+		ScoreAtLeast(0),
+		OmniscoreBelow(100),
+		TotalBelow(1 << 62),
+		RuleTriggered(""),
+		KCEventTriggered(""),
+	} {
+		if c.Match(in) {
+			t.Errorf("%s matched a response without the value", c)
+		}
+	}
+}
+
+func TestConditionDescriptions(t *testing.T) {
+	c := Or(And(Auto(response.DecisionReview), ScoreBelow(30)), Not(TotalBelow(5000)))
+	want := "(AUTO=R and SCOR<30 or not (TOTL<5000))"
+	if c.String() != want {
+		t.Errorf("got %q, want %q", c.String(), want)
+	}
+}
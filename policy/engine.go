@@ -0,0 +1,88 @@
+/**
+ * The policy package turns a RIS response into the merchant's own action.
+ * Policies are evaluated in the order they were added and the first one whose
+ * condition matches decides the action, e.g.
+ *
+ *	engine := policy.NewEngine("review")
+ *	engine.Add("small low-risk orders", policy.And(
+ *		policy.Auto(response.DecisionReview),
+ *		policy.ScoreBelow(30),
+ *		policy.TotalBelow(5000),
+ *	), "approve")
+ *	engine.Add("known bad rule", policy.RuleTriggered("12345"), "manual-review")
+ *	result, err := engine.Evaluate(resp, inquiry)
+ */
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/response"
+)
+
+var ErrNoResponse = errors.New("policy: no response to evaluate")
+
+// Action is the merchant defined outcome of a policy, e.g. "approve".
+type Action string
+
+type Policy struct {
+	Name   string
+	When   Condition
+	Action Action
+}
+
+// Result is the outcome of evaluating the engine's policies.
+type Result struct {
+	Action      Action
+	Policy      string // name of the matching policy, empty if none matched
+	Matched     bool   // false when the default action was used
+	Explanation string
+}
+
+type Engine struct {
+	policies      []Policy
+	defaultAction Action
+}
+
+// NewEngine creates an engine that falls back to defaultAction when no
+// policy matches.
+func NewEngine(defaultAction Action) *Engine {
+	return &Engine{defaultAction: defaultAction}
+}
+
+// Add a policy after the ones already registered.
+func (e *Engine) Add(name string, when Condition, action Action) {
+	e.policies = append(e.policies, Policy{Name: name, When: when, Action: action})
+}
+
+// Get the registered policies in evaluation order.
+func (e *Engine) GetPolicies() []Policy {
+	policies := make([]Policy, len(e.policies))
+	copy(policies, e.policies)
+	return policies
+}
+
+// Evaluate the policies against a response and the inquiry it answered.
+// The inquiry may be nil; a nil response returns ErrNoResponse, since no
+// action can be chosen without one.
+func (e *Engine) Evaluate(resp *response.Response, inquiry *request.Inquiry) (Result, error) {
+	if resp == nil {
+		return Result{}, ErrNoResponse
+	}
+	in := Input{Response: resp, Inquiry: inquiry}
+	for _, p := range e.policies {
+		if p.When.Match(in) {
+			return Result{
+				Action:      p.Action,
+				Policy:      p.Name,
+				Matched:     true,
+				Explanation: fmt.Sprintf("policy %q matched (%s): %s", p.Name, p.When, p.Action),
+			}, nil
+		}
+	}
+	return Result{
+		Action:      e.defaultAction,
+		Explanation: fmt.Sprintf("no policy matched (AUTO=%s): default %s", resp.GetAuto(), e.defaultAction),
+	}, nil
+}
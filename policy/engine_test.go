@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/phpsquid/kount/response"
+)
+
+func TestEngineFirstMatchWins(t *testing.T) {
+	engine := NewEngine("review")
+	engine.Add("low score", ScoreBelow(30), "approve")
+	engine.Add("reviewed by RIS", Auto(response.DecisionReview), "manual-review")
+	engine.Add("any score", ScoreAtLeast(0), "decline")
+
+	tests := []struct {
+		data   map[string]string
+		policy string
+		action Action
+	}{
+		{map[string]string{"AUTO": "R", "SCOR": "10"}, "low score", "approve"},
+		{map[string]string{"AUTO": "R", "SCOR": "50"}, "reviewed by RIS", "manual-review"},
+		{map[string]string{"AUTO": "A", "SCOR": "50"}, "any score", "decline"},
+	}
+	for _, test := range tests {
+		result, err := engine.Evaluate(&response.Response{Data: test.data}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Matched || result.Policy != test.policy || result.Action != test.action {
+			t.Errorf("%v: got %+v", test.data, result)
+		}
+	}
+}
+
+func TestEngineDefaultAction(t *testing.T) {
+	engine := NewEngine("review")
+	engine.Add("low score", ScoreBelow(30), "approve")
+
+	result, err := engine.Evaluate(&response.Response{Data: map[string]string{"AUTO": "D", "SCOR": "90"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Matched || result.Policy != "" || result.Action != "review" {
+		t.Errorf("got %+v", result)
+	}
+	if result.Explanation != "no policy matched (AUTO=D): default review" {
+		t.Errorf("explanation %q", result.Explanation)
+	}
+}
+
+func TestEngineRejectsNilResponse(t *testing.T) {
+	engine := NewEngine("review")
+	engine.Add("declined", Auto(response.DecisionDecline), "decline")
+	if _, err := engine.Evaluate(nil, nil); !errors.Is(err, ErrNoResponse) {
+		t.Errorf("got %v, want ErrNoResponse", err)
+	}
+}
+
+func TestGetPoliciesReturnsACopy(t *testing.T) {
+	engine := NewEngine("review")
+	engine.Add("first", ScoreBelow(30), "approve")
+	engine.Add("second", ScoreAtLeast(90), "decline")
+
+	policies := engine.GetPolicies()
+	if len(policies) != 2 || policies[0].Name != "first" || policies[1].Name != "second" {
+		t.Fatalf("got %+v", policies)
+	}
+	policies[0].Action = "decline"
+	if engine.GetPolicies()[0].Action != "approve" {
+		t.Error("changing the returned policies changed the engine")
+	}
+}
@@ -3,16 +3,24 @@ package request
 import (
 	"fmt"
 	"github.com/phpsquid/kount/data"
+	"github.com/phpsquid/kount/datacollector"
+	"github.com/phpsquid/kount/normalize"
+	"github.com/phpsquid/kount/response"
 	"github.com/phpsquid/kount/settings"
+	"net/http"
+	"time"
 )
 
 type Inquiry struct {
 	*Request
+	collectorStore    datacollector.Store
+	deviceDataWait    time.Duration
+	canonicalEmailUDF string
 }
 
 func NewInquiry(settings *settings.Settings) *Inquiry {
 	req := newRequest(settings)
-	i := &Inquiry{req}
+	i := &Inquiry{Request: req}
 	// defaults
 	i.SetMode("Q")
 	i.SetCurrency("USD")
@@ -20,6 +28,24 @@ func NewInquiry(settings *settings.Settings) *Inquiry {
 	return i
 }
 
+/**
+ * Create an inquiry for a tenant of the registry, using a copy of the
+ * tenant's settings and its website. Before it is sent, its merchant id and
+ * API key are checked against each other and the registry.
+ */
+func NewInquiryForTenant(registry *settings.Registry, name string) (*Inquiry, error) {
+	tenant, err := registry.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	i := NewInquiry(tenant.Settings)
+	i.registry, i.tenant = registry, name
+	if tenant.Site != "" {
+		i.SetWebsite(tenant.Site)
+	}
+	return i, nil
+}
+
 /**
  * Set the inquiry mode
  * Acceptable values are: "Q", "P", "W", "J"
@@ -59,14 +85,54 @@ func (i *Inquiry) SetIpAddress(ipAddress string) {
 	i.Request.SetParm("IPAD", ipAddress)
 }
 
-// Set the email address of the client.
-func (i *Inquiry) SetEmail(email string) {
-	i.Request.SetParm("EMAL", email)
+/**
+ * Set the email address of the client, trimmed, lowercased and with an
+ * internationalized domain converted to ASCII. If the address is malformed
+ * EMAL is left unset and the error is returned.
+ */
+func (i *Inquiry) SetEmail(email string) error {
+	normalized, err := normalize.Email(email)
+	if err != nil {
+		delete(i.data, "EMAL")
+	} else {
+		i.Request.SetParm("EMAL", normalized)
+	}
+	i.setCanonicalEmail()
+	return err
 }
 
-// Set the ANI (Automatic Identification Number) received for the phone transaction.
-func (i *Inquiry) SetANID(anid string) {
-	i.Request.SetParm("ANID", anid)
+/**
+ * Send the provider canonical form of the client's email address, e.g.
+ * "john@gmail.com" for "J.o.hn+shop@googlemail.com", in the named user
+ * defined field. EMAL itself is never canonicalized.
+ */
+func (i *Inquiry) SetCanonicalEmailUDF(label string) {
+	i.canonicalEmailUDF = label
+	i.setCanonicalEmail()
+}
+
+// Set the canonical email UDF from EMAL, or remove it when EMAL is unset or
+// has no canonical form, so it never describes a previous address.
+func (i *Inquiry) setCanonicalEmail() {
+	if i.canonicalEmailUDF == "" {
+		return
+	}
+	email, ok := i.data["EMAL"]
+	canonical, err := normalize.CanonicalEmail(email)
+	if !ok || err != nil {
+		delete(i.data, "UDF["+i.canonicalEmailUDF+"]")
+		return
+	}
+	i.SetUserDefinedField(i.canonicalEmailUDF, canonical)
+}
+
+/**
+ * Set the ANI (Automatic Identification Number) received for the phone transaction.
+ * The number is normalized to E.164 like the billing phone number, and ANID
+ * is left unset when it cannot be normalized.
+ */
+func (i *Inquiry) SetANID(anid string) error {
+	return i.setPhone("ANID", anid, i.data["B2CC"])
 }
 
 // Set the name of the client.
@@ -112,9 +178,13 @@ func (i *Inquiry) SetBillingAddress(address1, address2, city, state,
 	}
 }
 
-// Set the billing phone number
-func (i *Inquiry) SetBillingPhoneNumber(phoneNumber string) {
-	i.Request.SetParm("B2PN", phoneNumber)
+/**
+ * Set the billing phone number, normalized to E.164. National numbers use the
+ * billing country as their region, so set the billing address first. If the
+ * number cannot be normalized B2PN is left unset and the error is returned.
+ */
+func (i *Inquiry) SetBillingPhoneNumber(phoneNumber string) error {
+	return i.setPhone("B2PN", phoneNumber, i.data["B2CC"])
 }
 
 // Set the shipping address
@@ -135,9 +205,30 @@ func (i *Inquiry) SetShippingAddress(address1, address2, city, state,
 	}
 }
 
-// Set the shipping phone number
-func (i *Inquiry) SetShippingPhoneNumber(phoneNumber string) {
-	i.Request.SetParm("S2PN", phoneNumber)
+/**
+ * Set the shipping phone number, normalized to E.164. National numbers use the
+ * shipping country as their region, or the billing country when no shipping
+ * address is set. If the number cannot be normalized S2PN is left unset and
+ * the error is returned.
+ */
+func (i *Inquiry) SetShippingPhoneNumber(phoneNumber string) error {
+	region := i.data["S2CC"]
+	if region == "" {
+		region = i.data["B2CC"]
+	}
+	return i.setPhone("S2PN", phoneNumber, region)
+}
+
+// Set a phone number parameter normalized to E.164, or remove it when the
+// number is invalid so Kount never receives a malformed or stale number.
+func (i *Inquiry) setPhone(key, phoneNumber, region string) error {
+	normalized, err := normalize.Phone(phoneNumber, region)
+	if err != nil {
+		delete(i.data, key)
+		return err
+	}
+	i.Request.SetParm(key, normalized)
+	return nil
 }
 
 // Set the shipping name
@@ -145,9 +236,18 @@ func (i *Inquiry) SetShippingName(name string) {
 	i.Request.SetParm("S2NM", name)
 }
 
-// Set the shipping email
-func (i *Inquiry) SetShippingEmail(emailAddress string) {
-	i.Request.SetParm("S2EM", emailAddress)
+/**
+ * Set the shipping email, normalized like the client's email address. If the
+ * address is malformed S2EM is left unset and the error is returned.
+ */
+func (i *Inquiry) SetShippingEmail(emailAddress string) error {
+	normalized, err := normalize.Email(emailAddress)
+	if err != nil {
+		delete(i.data, "S2EM")
+		return err
+	}
+	i.Request.SetParm("S2EM", normalized)
+	return nil
 }
 
 // Set the user agent string
@@ -155,6 +255,58 @@ func (i *Inquiry) SetUserAgent(userAgent string) {
 	i.Request.SetParm("UAGT", userAgent)
 }
 
+/**
+ * Set the session id from the cookie written by the data collector handler,
+ * so the inquiry uses the same SESS value as the device data collection.
+ * An empty cookie name uses datacollector.DefaultCookieName.
+ */
+func (i *Inquiry) SetSessionFromRequest(r *http.Request, cookieName string) error {
+	id, err := datacollector.SessionFromRequest(r, cookieName)
+	if err != nil {
+		return err
+	}
+	i.SetSessionID(id)
+	return nil
+}
+
+// Set the store used to check whether the data collector ran for this session.
+func (i *Inquiry) SetCollectorStore(store datacollector.Store) {
+	i.collectorStore = store
+}
+
+/**
+ * Set how long GetResponse waits for the data collector to complete for this
+ * session before sending the inquiry. Zero sends immediately.
+ */
+func (i *Inquiry) SetDeviceDataWait(timeout time.Duration) {
+	i.deviceDataWait = timeout
+}
+
+/**
+ * Report whether the data collector has run for the inquiry's session id.
+ * Always false when no collector store or session id has been set.
+ */
+func (i *Inquiry) DeviceDataCollected() (bool, error) {
+	return i.WaitForDeviceData(0)
+}
+
+// Wait up to timeout for the data collector to run for the inquiry's session id.
+func (i *Inquiry) WaitForDeviceData(timeout time.Duration) (bool, error) {
+	sessionID := i.data["SESS"]
+	if i.collectorStore == nil || sessionID == "" {
+		return false, nil
+	}
+	return datacollector.WaitForCollection(i.collectorStore, sessionID, timeout)
+}
+
+/**
+ * Set the long BIN, the first 6 to 8 digits of the card number. Only sent
+ * with RIS version 0710 or later.
+ */
+func (i *Inquiry) SetLBIN(lbin string) {
+	i.Request.SetParm("LBIN", lbin)
+}
+
 // Set the website id (shortname) associated with this transaction
 func (i *Inquiry) SetWebsite(site string) {
 	i.Request.SetParm("SITE", site)
@@ -180,3 +332,17 @@ func (i *Inquiry) SetCart(cart []data.CartItem) {
 		i.addItemToCart(index, item)
 	}
 }
+
+/**
+ * Send the inquiry. When a collector store and a device data wait are set,
+ * the inquiry is held until the data collector has run for the session or
+ * the wait elapses, whichever comes first.
+ */
+func (i *Inquiry) GetResponse() (*response.Response, error) {
+	if i.deviceDataWait > 0 {
+		if _, err := i.WaitForDeviceData(i.deviceDataWait); err != nil {
+			return &response.Response{}, err
+		}
+	}
+	return i.Request.GetResponse()
+}
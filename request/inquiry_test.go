@@ -0,0 +1,110 @@
+package request_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phpsquid/kount/kounttest"
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/response"
+	"github.com/phpsquid/kount/settings"
+)
+
+func TestInvalidPhoneNumbersAreNotSent(t *testing.T) {
+	inquiry := request.NewInquiry(settings.New("123456", "https://risk.test.kount.net", "key", "config"))
+	inquiry.SetBillingAddress("", "", "", "", "", "US", "", "")
+
+	if err := inquiry.SetBillingPhoneNumber("(555) 123-4567"); err != nil {
+		t.Fatal(err)
+	}
+	if got := inquiry.GetParm("B2PN"); got != "+15551234567" {
+		t.Fatalf("B2PN = %q", got)
+	}
+	if err := inquiry.SetBillingPhoneNumber("123"); err == nil {
+		t.Error("invalid billing phone number accepted")
+	}
+	if got := inquiry.GetParm("B2PN"); got != "" {
+		t.Errorf("B2PN = %q after an invalid number", got)
+	}
+	if err := inquiry.SetShippingPhoneNumber("not a number"); err == nil {
+		t.Error("invalid shipping phone number accepted")
+	}
+	if got := inquiry.GetParm("S2PN"); got != "" {
+		t.Errorf("S2PN = %q after an invalid number", got)
+	}
+	if err := inquiry.SetANID("(055) 123-4567"); err == nil {
+		t.Error("invalid ANID accepted")
+	}
+	if got := inquiry.GetParm("ANID"); got != "" {
+		t.Errorf("ANID = %q after an invalid number", got)
+	}
+}
+
+func TestInvalidEmailsAreNotSent(t *testing.T) {
+	inquiry := request.NewInquiry(settings.New("123456", "https://risk.test.kount.net", "key", "config"))
+	inquiry.SetCanonicalEmailUDF("CANONICAL_EMAIL")
+
+	if err := inquiry.SetEmail("J.o.hn+shop@GoogleMail.com"); err != nil {
+		t.Fatal(err)
+	}
+	if got := inquiry.GetParm("EMAL"); got != "j.o.hn+shop@googlemail.com" {
+		t.Errorf("EMAL = %q", got)
+	}
+	if got := inquiry.GetParm("UDF[CANONICAL_EMAIL]"); got != "john@gmail.com" {
+		t.Errorf("canonical email = %q", got)
+	}
+
+	if err := inquiry.SetEmail("John <john@example.com>"); err == nil {
+		t.Error("display name accepted")
+	}
+	if got := inquiry.GetParm("EMAL"); got != "" {
+		t.Errorf("EMAL = %q after an invalid address", got)
+	}
+	if got := inquiry.GetParm("UDF[CANONICAL_EMAIL]"); got != "" {
+		t.Errorf("canonical email = %q after an invalid address", got)
+	}
+
+	if err := inquiry.SetShippingEmail("not an address"); err == nil {
+		t.Error("invalid shipping email accepted")
+	}
+	if got := inquiry.GetParm("S2EM"); got != "" {
+		t.Errorf("S2EM = %q after an invalid address", got)
+	}
+}
+
+func TestResponseRequestIsRedacted(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+
+	inquiry := request.NewInquiry(srv.Settings())
+	inquiry.SetBillingAddress("1 Main St", "", "Seattle", "WA", "98101", "US", "", "")
+	if err := inquiry.SetEmail("john@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	inquiry.SetName("John Smith")
+	inquiry.SetCardPayment("4111111111111111")
+	inquiry.SetTotal("1000")
+
+	resp, err := inquiry.GetResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"EMAL", "NAME", "B2A1", "B2CI", "B2PC", "PTOK"} {
+		if got := resp.Request[key]; got != response.Redacted {
+			t.Errorf("Request[%s] = %q, want it redacted", key, got)
+		}
+	}
+	for key, want := range map[string]string{"B2CC": "US", "TOTL": "1000", "B2A2": ""} {
+		if got := resp.Request[key]; got != want {
+			t.Errorf("Request[%s] = %q, want %q", key, got, want)
+		}
+	}
+
+	serialized, err := resp.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(serialized), "john@example.com") || strings.Contains(string(serialized), "1 Main St") {
+		t.Errorf("serialized response leaks personal data: %s", serialized)
+	}
+}
@@ -2,11 +2,13 @@
 package request
 
 import (
+	"errors"
 	"github.com/phpsquid/kount/response"
 	"github.com/phpsquid/kount/settings"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -37,12 +39,18 @@ const (
 	ConnectionTimeout = 30
 )
 
+var ErrMerchantMismatch = errors.New("request: merchant id does not match the settings or API key")
+
 type Request struct {
 	*settings.Settings
 	data              map[string]string
 	connectionTimeout int
 	url               string
 	apiKey            string
+
+	// the registry and tenant the request was created for, if any
+	registry *settings.Registry
+	tenant   string
 }
 
 func newRequest(settings *settings.Settings) *Request {
@@ -61,6 +69,11 @@ func (r *Request) SetParm(key, value string) {
 	r.data[key] = value
 }
 
+// Get a parameter of the request. Empty if it has not been set.
+func (r *Request) GetParm(key string) string {
+	return r.data[key]
+}
+
 // Set the merchant id assigned by Kount.
 func (r *Request) SetMerchantID(id string) {
 	r.data["MERC"] = id
@@ -76,7 +89,11 @@ func (r *Request) SetConnectionTimeout(timeout int) {
 	r.connectionTimeout = timeout
 }
 
-// Set the version number
+/**
+ * Set the RIS version of this request, overriding the settings. When unset
+ * the settings version for the request's website is used, then the settings
+ * default version, then Version.
+ */
 func (r *Request) SetVersion(version string) {
 	r.data["VERS"] = version
 }
@@ -130,6 +147,18 @@ func (r *Request) SetCVVR(cvvr string) {
 	r.data["CVVR"] = cvvr
 }
 
+/**
+ * Set the format RIS should answer in, response.FormatJSON or
+ * response.FormatXML. response.FormatKeyValue restores the default format.
+ */
+func (r *Request) SetFormat(format string) {
+	if format == response.FormatKeyValue {
+		delete(r.data, "FRMT")
+		return
+	}
+	r.data["FRMT"] = format
+}
+
 // Set the RIS target server URL
 func (r *Request) SetURL(url string) {
 	r.url = url
@@ -323,43 +352,143 @@ func (r *Request) SetPayment(paymentType, paymentToken string) {
 	r.data["PTOK"] = paymentToken
 }
 
+// Copy the request parameters for the response, with payment and personal
+// data redacted like a cassette recording.
+func (r *Request) sentParameters(form url.Values) map[string]string {
+	params := make(map[string]string, len(form))
+	for key := range form {
+		params[key] = form.Get(key)
+	}
+	return response.RedactFields(params, response.RedactedRequestFields)
+}
+
 func (r *Request) GetResponse() (*response.Response, error) {
 	myResp := &response.Response{}
-	client := &http.Client{}
+	client := &http.Client{Transport: r.Settings.GetTransport()}
 
 	// set timeout
 	client.Timeout = time.Second * time.Duration(ConnectionTimeout)
 
-	r.SetVersion(Version)
+	if err := r.Settings.CheckEndpoint(); err != nil {
+		return myResp, err
+	}
+
+	version, err := r.resolveVersion()
+	if err != nil {
+		return myResp, err
+	}
 
 	form := url.Values{}
+	form.Set("VERS", version)
 	for key, value := range r.data {
+		// leave out fields the version does not know yet
+		if key == "VERS" || !FieldSupported(version, key) {
+			continue
+		}
 		form.Add(key, value)
 	}
 
-	req, err := http.NewRequest("POST", r.Settings.GetRISURL(), strings.NewReader(form.Encode()))
+	credentials, err := r.Settings.GetCredentials()
 	if err != nil {
 		return myResp, err
 	}
+	if err := r.checkTenant(credentials); err != nil {
+		return myResp, err
+	}
+	myResp, status, err := r.send(client, form, credentials.APIKey)
+	// the body of a 401 or 403 need not be a RIS response, so the status is
+	// checked before a digest error is returned
+	if err != nil && !authFailed(status) || err == nil && !unauthorized(myResp, status) {
+		return myResp, err
+	}
+
+	// during a key rotation RIS may not accept the new key yet; the previous
+	// key passed checkTenant when it was current
+	previous, ok := r.Settings.GetPreviousCredentials()
+	if !ok || previous.APIKey == credentials.APIKey || r.registry != nil && r.checkMerchant(previous) != nil {
+		return myResp, err
+	}
+	myResp, _, err = r.send(client, form, previous.APIKey)
+	return myResp, err
+}
+
+/**
+ * Check a request created for a tenant before it is sent: its merchant id
+ * and API key must match, and the resolved API key must still obey the
+ * registry's sharing rules. Other requests are not checked.
+ */
+func (r *Request) checkTenant(credentials settings.Credentials) error {
+	if r.registry == nil {
+		return nil
+	}
+	if err := r.checkMerchant(credentials); err != nil {
+		return err
+	}
+	return r.registry.CheckCredentials(r.tenant, credentials)
+}
+
+/**
+ * Refuse to send a request whose merchant id differs from the settings', or
+ * whose API key, if it is a JWT, was issued for another merchant.
+ */
+func (r *Request) checkMerchant(credentials settings.Credentials) error {
+	if r.data["MERC"] != r.Settings.GetMerchantID() {
+		return ErrMerchantMismatch
+	}
+	claims, err := settings.DecodeAPIKey(credentials.APIKey)
+	if err == nil && claims.Subject != r.data["MERC"] {
+		return ErrMerchantMismatch
+	}
+	return nil
+}
+
+// Post the form to RIS and read the response and its HTTP status.
+func (r *Request) send(client *http.Client, form url.Values, apiKey string) (*response.Response, int, error) {
+	myResp := &response.Response{}
+
+	req, err := http.NewRequest("POST", r.Settings.GetRISURL(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return myResp, 0, err
+	}
 
 	// set request headers
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("X-Kount-Api-Key", r.Settings.GetAPIKey())
+	req.Header.Add("X-Kount-Api-Key", apiKey)
 	req.Header.Add("X-Kount-Merc-Id", r.data["MERC"])
 
+	myResp.Request = r.sentParameters(form)
+	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
-		return myResp, err
+		return myResp, 0, err
 	}
 
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return myResp, err
+		return myResp, resp.StatusCode, err
 	}
 
+	myResp.ReceivedAt = time.Now()
+	myResp.Latency = myResp.ReceivedAt.Sub(start)
 	myResp.Raw = string(body)
-	myResp.Digest()
-	return myResp, nil
+	if err := myResp.Digest(); err != nil {
+		return myResp, resp.StatusCode, err
+	}
+	return myResp, resp.StatusCode, nil
+}
+
+// Report whether the HTTP status rejects the request's credentials.
+func authFailed(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// Report whether RIS rejected the request's credentials.
+func unauthorized(resp *response.Response, status int) bool {
+	if authFailed(status) {
+		return true
+	}
+	code, _ := strconv.Atoi(resp.GetErrorCode())
+	return code == response.CodeUnauthorized || code == response.CodeUnauthorizedPass
 }
@@ -0,0 +1,93 @@
+package request_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/settings"
+)
+
+// Serve a RIS that rejects every API key but "old-key" with the given
+// status and body, recording the keys it was sent.
+func rotationServer(t *testing.T, status int, body string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-Kount-Api-Key")
+		mu.Lock()
+		keys = append(keys, key)
+		mu.Unlock()
+		if key != "old-key" {
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+			return
+		}
+		w.Write([]byte("MODE=Q\nMERC=123456\nAUTO=A\n"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), keys...)
+	}
+}
+
+// Create settings whose provider has rotated from "old-key" to "new-key".
+func rotatedSettings(t *testing.T, risURL string) *settings.Settings {
+	t.Setenv("KOUNTTEST_ROTATING_API_KEY", "old-key")
+	provider := settings.NewEnvProvider("KOUNTTEST_ROTATING_API_KEY", "")
+	if _, err := provider.Credentials(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KOUNTTEST_ROTATING_API_KEY", "new-key")
+
+	s := settings.New("123456", risURL, "", "config")
+	s.SetCredentialProvider(provider)
+	return s
+}
+
+func TestGetResponseFallsBackToPreviousKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"plain 401", http.StatusUnauthorized, "Unauthorized"},
+		{"plain 403", http.StatusForbidden, "<html><body>Forbidden</body></html>"},
+		{"401 with ERRO", http.StatusUnauthorized, "MODE=E\nERRO=501\nERROR_COUNT=1\nERROR_0=501 UNAUTH_REQ\n"},
+		{"ERRO with 200", http.StatusOK, "MODE=E\nERRO=501\nERROR_COUNT=1\nERROR_0=501 UNAUTH_REQ\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, sentKeys := rotationServer(t, test.status, test.body)
+			inquiry := request.NewInquiry(rotatedSettings(t, srv.URL))
+			inquiry.SetSessionID("SESSION")
+
+			resp, err := inquiry.GetResponse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.GetMode() != "Q" || resp.GetAuto() != "A" {
+				t.Errorf("got %v", resp.Data)
+			}
+			if keys := sentKeys(); len(keys) != 2 || keys[0] != "new-key" || keys[1] != "old-key" {
+				t.Errorf("sent keys %q, want new-key then old-key", keys)
+			}
+		})
+	}
+}
+
+func TestGetResponseReportsUnauthorizedWithoutPreviousKey(t *testing.T) {
+	srv, sentKeys := rotationServer(t, http.StatusUnauthorized, "Unauthorized")
+	inquiry := request.NewInquiry(settings.New("123456", srv.URL, "new-key", "config"))
+
+	if _, err := inquiry.GetResponse(); err == nil {
+		t.Error("want the digest error of the 401 body")
+	}
+	if keys := sentKeys(); len(keys) != 1 {
+		t.Errorf("sent keys %q, want one attempt", keys)
+	}
+}
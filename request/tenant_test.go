@@ -0,0 +1,81 @@
+package request_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/phpsquid/kount/kounttest"
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/settings"
+)
+
+func TestInquiryForTenant(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+	reg := settings.NewRegistry()
+	if err := reg.Register("shop", srv.Settings(), "SHOP"); err != nil {
+		t.Fatal(err)
+	}
+
+	inquiry, err := request.NewInquiryForTenant(reg, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inquiry.GetResponse(); err != nil {
+		t.Fatal(err)
+	}
+	if last, _ := srv.LastRequest(); last.Form.Get("SITE") != "SHOP" || last.Form.Get("MERC") != "123456" {
+		t.Errorf("sent %v", last.Form)
+	}
+
+	inquiry.SetMerchantID("999999")
+	if _, err := inquiry.GetResponse(); err != request.ErrMerchantMismatch {
+		t.Errorf("other merchant id: got %v", err)
+	}
+	if _, err := request.NewUpdateForTenant(reg, "missing"); err == nil {
+		t.Error("unknown tenant: want an error")
+	}
+}
+
+func TestOnlyTenantRequestsCheckTheMerchant(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+
+	inquiry := request.NewInquiry(srv.Settings())
+	inquiry.SetMerchantID("999999")
+	if _, err := inquiry.GetResponse(); err != nil {
+		t.Fatalf("request without a tenant: got %v", err)
+	}
+	if last, _ := srv.LastRequest(); last.Form.Get("MERC") != "999999" {
+		t.Errorf("sent MERC %q", last.Form.Get("MERC"))
+	}
+}
+
+func TestTenantRequestRejectsRotatedSharedKey(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+	reg := settings.NewRegistry()
+	if err := reg.Register("shop", srv.Settings(), "SHOP"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KOUNTTEST_OUTLET_API_KEY", "outlet-api-key")
+	outlet := settings.New("654321", srv.Settings().GetRISURL(), "", "config")
+	outlet.SetCredentialProvider(settings.NewEnvProvider("KOUNTTEST_OUTLET_API_KEY", ""))
+	if err := reg.Register("outlet", outlet, "OUTLET"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the outlet's provider rotates to the shop's key after registration
+	t.Setenv("KOUNTTEST_OUTLET_API_KEY", "test-api-key")
+	update, err := request.NewUpdateForTenant(reg, "outlet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	update.SetTransactionId("TRAN")
+	if _, err := update.GetResponse(); !errors.Is(err, settings.ErrCredentialConflict) {
+		t.Errorf("got %v, want ErrCredentialConflict", err)
+	}
+	if _, ok := srv.LastRequest(); ok {
+		t.Error("the request was sent")
+	}
+}
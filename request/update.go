@@ -15,6 +15,18 @@ func NewUpdate(settings *settings.Settings) *Update {
 	return u
 }
 
+// Create an update for a tenant of the registry, using a copy of the tenant's
+// settings. It is checked like an inquiry created by NewInquiryForTenant.
+func NewUpdateForTenant(registry *settings.Registry, name string) (*Update, error) {
+	tenant, err := registry.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	u := NewUpdate(tenant.Settings)
+	u.registry, u.tenant = registry, name
+	return u, nil
+}
+
 /**
  * Set the update mode
  * Acceptable values are: "U" or "X"
@@ -0,0 +1,67 @@
+package request
+
+import (
+	"errors"
+	"github.com/phpsquid/kount/response"
+	"sort"
+)
+
+var ErrUnsupportedVersion = errors.New("request: unsupported RIS version")
+
+// RIS versions this SDK can send, oldest first: the versions whose response
+// fields the response package documents.
+var SupportedVersions = response.DocumentedVersions()
+
+// request fields introduced after 0700, by the version that added them
+var fieldsAdded = map[string][]string{
+	"0710": {"LBIN"},
+	// device data sent by native apps that collect it themselves
+	"0720": {"DEVICE_ID", "DEVICE_TYPE", "DEVICE_OS", "DEVICE_OS_VERSION"},
+}
+
+// the version each later field was introduced in
+var fieldVersions = func() map[string]string {
+	versions := make(map[string]string)
+	for version, keys := range fieldsAdded {
+		for _, key := range keys {
+			versions[key] = version
+		}
+	}
+	return versions
+}()
+
+// Report whether this SDK can send the RIS version.
+func VersionSupported(version string) bool {
+	i := sort.SearchStrings(SupportedVersions, version)
+	return i < len(SupportedVersions) && SupportedVersions[i] == version
+}
+
+// Report whether a request field can be sent with the RIS version.
+func FieldSupported(version, key string) bool {
+	introduced, ok := fieldVersions[key]
+	return !ok || version >= introduced
+}
+
+/**
+ * Get the RIS version the request is sent with: a version set on the request
+ * wins, then the settings version for the request's website, then the
+ * settings default version, then Version.
+ */
+func (r *Request) GetVersion() string {
+	if version := r.data["VERS"]; version != "" {
+		return version
+	}
+	if version := r.Settings.GetWebsiteVersion(r.data["SITE"]); version != "" {
+		return version
+	}
+	return Version
+}
+
+// Resolve the RIS version of the request, failing if it is not supported.
+func (r *Request) resolveVersion() (string, error) {
+	version := r.GetVersion()
+	if !VersionSupported(version) {
+		return "", ErrUnsupportedVersion
+	}
+	return version, nil
+}
@@ -0,0 +1,118 @@
+package request_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/phpsquid/kount/kounttest"
+	"github.com/phpsquid/kount/request"
+	"github.com/phpsquid/kount/response"
+	"github.com/phpsquid/kount/settings"
+)
+
+func TestSupportedVersions(t *testing.T) {
+	if !reflect.DeepEqual(request.SupportedVersions, response.DocumentedVersions()) {
+		t.Errorf("supported %v, documented %v", request.SupportedVersions, response.DocumentedVersions())
+	}
+	for _, version := range request.SupportedVersions {
+		if !request.VersionSupported(version) {
+			t.Errorf("%s is not supported", version)
+		}
+	}
+	for _, version := range []string{"", "0600", "0730", "710"} {
+		if request.VersionSupported(version) {
+			t.Errorf("%q is supported", version)
+		}
+	}
+}
+
+func TestFieldSupported(t *testing.T) {
+	tests := []struct {
+		version, key string
+		want         bool
+	}{
+		{"0700", "EMAL", true},
+		{"0700", "LBIN", false},
+		{"0710", "LBIN", true},
+		{"0720", "LBIN", true},
+		{"0710", "DEVICE_ID", false},
+		{"0720", "DEVICE_ID", true},
+		{"0720", "DEVICE_OS_VERSION", true},
+	}
+	for _, test := range tests {
+		if got := request.FieldSupported(test.version, test.key); got != test.want {
+			t.Errorf("FieldSupported(%q, %q) = %v, want %v", test.version, test.key, got, test.want)
+		}
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	s := settings.New("123456", "https://risk.test.kount.net", "key", "config")
+	inquiry := request.NewInquiry(s)
+	if got := inquiry.GetVersion(); got != request.Version {
+		t.Errorf("without settings got %q, want %q", got, request.Version)
+	}
+
+	s.SetDefaultVersion("0710")
+	if got := inquiry.GetVersion(); got != "0710" {
+		t.Errorf("with a default version got %q", got)
+	}
+
+	s.SetWebsiteVersion("SHOP", "0720")
+	inquiry.SetWebsite("SHOP")
+	if got := inquiry.GetVersion(); got != "0720" {
+		t.Errorf("with a website version got %q", got)
+	}
+
+	inquiry.SetVersion("0700")
+	if got := inquiry.GetVersion(); got != "0700" {
+		t.Errorf("with a request version got %q", got)
+	}
+	if got := s.GetDefaultVersion(); got != "0710" {
+		t.Errorf("the request version changed the settings to %q", got)
+	}
+}
+
+func TestVersionSelectsFields(t *testing.T) {
+	srv := kounttest.NewServer("123456", "test-api-key")
+	defer srv.Close()
+
+	for _, test := range []struct {
+		version string
+		sent    []string
+		dropped []string
+	}{
+		{"0700", nil, []string{"LBIN", "DEVICE_ID"}},
+		{"0710", []string{"LBIN"}, []string{"DEVICE_ID"}},
+		{"0720", []string{"LBIN", "DEVICE_ID"}, nil},
+	} {
+		inquiry := request.NewInquiry(srv.Settings())
+		inquiry.SetVersion(test.version)
+		inquiry.SetLBIN("41111111")
+		inquiry.SetParm("DEVICE_ID", "device-1")
+		if _, err := inquiry.GetResponse(); err != nil {
+			t.Fatal(err)
+		}
+		last, _ := srv.LastRequest()
+		if got := last.Form.Get("VERS"); got != test.version {
+			t.Errorf("%s: sent VERS=%q", test.version, got)
+		}
+		for _, key := range test.sent {
+			if _, ok := last.Form[key]; !ok {
+				t.Errorf("%s: %s was not sent", test.version, key)
+			}
+		}
+		for _, key := range test.dropped {
+			if _, ok := last.Form[key]; ok {
+				t.Errorf("%s: %s was sent", test.version, key)
+			}
+		}
+	}
+
+	inquiry := request.NewInquiry(srv.Settings())
+	inquiry.SetVersion("0999")
+	if _, err := inquiry.GetResponse(); !errors.Is(err, request.ErrUnsupportedVersion) {
+		t.Errorf("got %v, want ErrUnsupportedVersion", err)
+	}
+}
@@ -0,0 +1,104 @@
+package response
+
+import (
+	"math"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0088
+
+// Device holds what the data collector learned about the remote device.
+type Device struct {
+	Fingerprint      string `ris:"FINGERPRINT"`
+	Layers           string `ris:"DEVICE_LAYERS"`
+	MobileType       string `ris:"MOBILE_TYPE"`
+	MobileDevice     bool   `ris:"MOBILE_DEVICE"`
+	ScreenResolution string `ris:"DSR"`
+	OS               string `ris:"OS"`
+	Browser          string `ris:"BROWSER"`
+	UserAgent        string `ris:"UAS"`
+	Language         string `ris:"LANGUAGE"`
+	Country          string `ris:"COUNTRY"`
+	Cookies          bool   `ris:"COOKIES"`
+	JavaScript       bool   `ris:"JAVASCRIPT"`
+	Flash            bool   `ris:"FLASH"`
+}
+
+// Get the device fields of the response. Flags that are not "Y" or "N" are
+// reported in a FieldErrors.
+func (r *Response) Device() (Device, error) {
+	var d Device
+	err := r.decode(&d)
+	return d, err
+}
+
+// Get the geolocation of the IP address the request came from, which may be
+// a proxy.
+func (r *Response) ProxyIP() (IPInfo, error) {
+	var ip struct {
+		IPInfo `ris:"IP_"`
+	}
+	err := r.decode(&ip)
+	return ip.IPInfo, err
+}
+
+// Get the geolocation of the IP address found behind a proxy.
+func (r *Response) PiercedIP() (IPInfo, error) {
+	var ip struct {
+		IPInfo `ris:"PIP_"`
+	}
+	err := r.decode(&ip)
+	return ip.IPInfo, err
+}
+
+// Report whether the IP address has coordinates.
+func (ip IPInfo) Located() bool {
+	return ip.Address != "" && (ip.Latitude != 0 || ip.Longitude != 0)
+}
+
+// DistanceKm returns the great-circle distance between two located IP
+// addresses in kilometres.
+func DistanceKm(a, b IPInfo) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+/**
+ * Get the distance in kilometres between the proxy IP address and the IP
+ * address pierced behind it. ok is false unless both have coordinates.
+ */
+func (r *Response) ProxyDistanceKm() (distance float64, ok bool) {
+	proxy, err := r.ProxyIP()
+	if err != nil || !proxy.Located() {
+		return 0, false
+	}
+	pierced, err := r.PiercedIP()
+	if err != nil || !pierced.Located() {
+		return 0, false
+	}
+	return DistanceKm(proxy, pierced), true
+}
+
+/**
+ * Report whether the IP address country differs from the billing country
+ * (B2CC) of the inquiry this response answered. The pierced IP country is
+ * used when RIS found one, the proxy IP country otherwise. False when either
+ * country is unknown, e.g. for a response built without its request.
+ */
+func (r *Response) IPCountryDiffers() bool {
+	country := r.GetPiercedIPAddressCountry()
+	if country == "" {
+		country = r.GetIPAddressCountry()
+	}
+	billingCountry := r.Request["B2CC"]
+	if country == "" || billingCountry == "" {
+		return false
+	}
+	return !strings.EqualFold(country, billingCountry)
+}
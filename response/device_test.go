@@ -0,0 +1,68 @@
+package response
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestIPCountryDiffers(t *testing.T) {
+	tests := []struct {
+		data    map[string]string
+		request map[string]string
+		want    bool
+	}{
+		{map[string]string{"PIP_COUNTRY": "GB", "IP_COUNTRY": "US"}, map[string]string{"B2CC": "US"}, true},
+		{map[string]string{"PIP_COUNTRY": "us", "IP_COUNTRY": "GB"}, map[string]string{"B2CC": "US"}, false},
+		{map[string]string{"IP_COUNTRY": "GB"}, map[string]string{"B2CC": "US"}, true},
+		{map[string]string{"IP_COUNTRY": "US"}, map[string]string{"B2CC": "US"}, false},
+		{map[string]string{}, map[string]string{"B2CC": "US"}, false},
+		{map[string]string{"IP_COUNTRY": "GB"}, map[string]string{}, false},
+		{map[string]string{"IP_COUNTRY": "GB"}, nil, false},
+	}
+	for _, test := range tests {
+		resp := &Response{Data: test.data, Request: test.request}
+		if got := resp.IPCountryDiffers(); got != test.want {
+			t.Errorf("%v with request %v: got %v, want %v", test.data, test.request, got, test.want)
+		}
+	}
+}
+
+func TestProxyDistanceKm(t *testing.T) {
+	resp := &Response{Data: map[string]string{
+		"IP_IPAD":  "198.51.100.1",
+		"IP_LAT":   "51.5074",
+		"IP_LON":   "-0.1278",
+		"PIP_IPAD": "203.0.113.7",
+		"PIP_LAT":  "48.8566",
+		"PIP_LON":  "2.3522",
+	}}
+	distance, ok := resp.ProxyDistanceKm()
+	if !ok || math.Abs(distance-343.5) > 1 {
+		t.Errorf("got %v, %v; want about 343.5 km", distance, ok)
+	}
+
+	delete(resp.Data, "PIP_LAT")
+	delete(resp.Data, "PIP_LON")
+	if _, ok := resp.ProxyDistanceKm(); ok {
+		t.Error("distance reported without pierced coordinates")
+	}
+}
+
+func TestDevice(t *testing.T) {
+	resp := &Response{Data: map[string]string{
+		"FINGERPRINT": "D4A2E7B65F",
+		"OS":          "Windows 10",
+		"COOKIES":     "Y",
+		"JAVASCRIPT":  "N",
+		"FLASH":       "maybe",
+	}}
+	device, err := resp.Device()
+	var fieldErrs FieldErrors
+	if !errors.As(err, &fieldErrs) || len(fieldErrs) != 1 || fieldErrs[0].Key != "FLASH" {
+		t.Errorf("got error %v, want one for FLASH", err)
+	}
+	if device.Fingerprint != "D4A2E7B65F" || device.OS != "Windows 10" || !device.Cookies || device.JavaScript {
+		t.Errorf("got %+v", device)
+	}
+}
@@ -0,0 +1,100 @@
+package response
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrNotXMLName = errors.New("response: key cannot be written as an XML element")
+
+/**
+ * Encode writes response data in the given format, the inverse of Digest.
+ * Key=value lines and XML elements are written in key order. Keys that are
+ * not XML names, such as 3DS_MERCHANT_RESPONSE, cannot be written as XML and
+ * return ErrNotXMLName. It is meant for fakes and simulators that stand in
+ * for RIS.
+ */
+func Encode(data map[string]string, format string) (string, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	switch format {
+	case FormatJSON:
+		body, err := json.Marshal(data)
+		if err != nil {
+			return "", err
+		}
+		b.Write(body)
+	case FormatXML:
+		b.WriteString(xml.Header)
+		b.WriteString("<RIS>")
+		for _, key := range keys {
+			if !xmlName.MatchString(key) {
+				return "", fmt.Errorf("%w: %q", ErrNotXMLName, key)
+			}
+			b.WriteString("<" + key + ">")
+			if err := xml.EscapeText(&b, []byte(data[key])); err != nil {
+				return "", err
+			}
+			b.WriteString("</" + key + ">")
+		}
+		b.WriteString("</RIS>\n")
+	default:
+		for _, key := range keys {
+			// line breaks cannot be represented in the key=value format
+			value := strings.NewReplacer("\r", " ", "\n", " ").Replace(data[key])
+			b.WriteString(key + "=" + value + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// keys that can be written as XML element names as they are
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// request identifiers RIS echoes back in its response
+var echoedFields = []string{"VERS", "MODE", "MERC", "SESS", "ORDR", "SITE"}
+
+// EchoFields returns the request identifiers RIS echoes back, for fakes and
+// simulators building a response to request.
+func EchoFields(request map[string]string) map[string]string {
+	fields := make(map[string]string)
+	for _, key := range echoedFields {
+		if value := request[key]; value != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+/**
+ * ErrorFields returns the fields of a RIS error response (MODE=E) to request,
+ * listing each message as ERROR_n. ERRO is the code of the first message, or
+ * its name if it has no code.
+ */
+func ErrorFields(request map[string]string, errors ...string) map[string]string {
+	fields := EchoFields(request)
+	fields["MODE"] = "E"
+	if len(errors) > 0 {
+		first := ParseWarning(errors[0])
+		fields["ERRO"] = first.Name
+		if first.Code != 0 {
+			fields["ERRO"] = strconv.Itoa(first.Code)
+		}
+	}
+	fields["ERROR_COUNT"] = strconv.Itoa(len(errors))
+	for i, err := range errors {
+		fields["ERROR_"+strconv.Itoa(i)] = err
+	}
+	return fields
+}
@@ -0,0 +1,77 @@
+package response
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	data := map[string]string{
+		"AUTO":        "R",
+		"SCOR":        "42",
+		"UAS":         "Mozilla/5.0 (a=b) <&>",
+		"RULE_ID_0":   "10",
+		"REASON_CODE": "",
+	}
+	for _, format := range []string{FormatKeyValue, FormatJSON, FormatXML} {
+		body, err := Encode(data, format)
+		if err != nil {
+			t.Fatalf("%q: %v", format, err)
+		}
+		resp := &Response{Raw: body, Format: format}
+		if err := resp.Digest(); err != nil {
+			t.Fatalf("%q: %v", format, err)
+		}
+		if !reflect.DeepEqual(resp.Data, data) {
+			t.Errorf("%q: got %v, want %v", format, resp.Data, data)
+		}
+	}
+}
+
+func TestEncodeKeysThatAreNotXMLNames(t *testing.T) {
+	data := map[string]string{"AUTO": "A", "3DS_MERCHANT_RESPONSE": "Y"}
+	if _, err := Encode(data, FormatXML); !errors.Is(err, ErrNotXMLName) {
+		t.Errorf("XML: got %v, want ErrNotXMLName", err)
+	}
+	for _, format := range []string{FormatKeyValue, FormatJSON} {
+		body, err := Encode(data, format)
+		if err != nil {
+			t.Fatalf("%q: %v", format, err)
+		}
+		resp := &Response{Raw: body, Format: format}
+		if err := resp.Digest(); err != nil || resp.GetParam("3DS_MERCHANT_RESPONSE") != "Y" {
+			t.Errorf("%q: got %v, %v", format, resp.Data, err)
+		}
+	}
+}
+
+func TestParseXMLIgnoresAttributes(t *testing.T) {
+	data, err := ParseXML(`<RIS><AUTO>A</AUTO><FIELD name="3DS_MERCHANT_RESPONSE">Y</FIELD></RIS>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data["3DS_MERCHANT_RESPONSE"]; ok || data["FIELD"] != "Y" {
+		t.Errorf("got %v", data)
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	request := map[string]string{"MODE": "Q", "MERC": "123456", "SESS": "s1", "EMAL": "john@example.com"}
+	if got := EchoFields(request); !reflect.DeepEqual(got, map[string]string{"MODE": "Q", "MERC": "123456", "SESS": "s1"}) {
+		t.Errorf("echo: got %v", got)
+	}
+
+	got := ErrorFields(request, "204 MISSING_SESS", "321 BAD_EMAL")
+	want := map[string]string{
+		"MODE": "E", "MERC": "123456", "SESS": "s1",
+		"ERRO": "204", "ERROR_COUNT": "2",
+		"ERROR_0": "204 MISSING_SESS", "ERROR_1": "321 BAD_EMAL",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := ErrorFields(nil, "no code"); got["ERRO"] != "" || got["ERROR_0"] != "no code" {
+		t.Errorf("uncoded error: got %v", got)
+	}
+}
@@ -0,0 +1,202 @@
+package response
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// the RIS version assumed for responses without VERS
+const DefaultVersion = "0700"
+
+// response keys documented for RIS version 0700
+var fields0700 = []string{
+	"VERS", "MODE", "TRAN", "MERC", "SESS", "ORDR", "SITE", "AUTO", "REASON_CODE",
+	"SCOR", "OMNISCORE", "GEOX", "BRND", "REGN", "NETW", "KAPT", "KYCF", "CARDS",
+	"DEVICES", "EMAILS", "VELO", "VMAX", "DEVICE_LAYERS", "FINGERPRINT", "TIMEZONE",
+	"LOCALTIME", "REGION", "COUNTRY", "PROXY", "JAVASCRIPT", "FLASH", "COOKIES",
+	"HTTP_COUNTRY", "LANGUAGE", "MOBILE_DEVICE", "MOBILE_TYPE", "MOBILE_FORWARDER",
+	"VOICE_DEVICE", "PC_REMOTE", "MASTERCARD", "DDFS", "DSR", "UAS", "BROWSER", "OS",
+	"PIP_IPAD", "PIP_LAT", "PIP_LON", "PIP_COUNTRY", "PIP_REGION", "PIP_CITY", "PIP_ORG",
+	"IP_IPAD", "IP_LAT", "IP_LON", "IP_COUNTRY", "IP_REGION", "IP_CITY", "IP_ORG",
+	"RULES_TRIGGERED", "COUNTERS_TRIGGERED", "WARNING_COUNT", "ERROR_COUNT", "ERRO",
+	"KC_CUSTOMER_ID", "KC_DECISION", "KC_WARNING_COUNT", "KC_ERROR_COUNT",
+	"KC_TRIGGERED_COUNT",
+}
+
+// response keys added by each version after 0700
+var fieldsAdded = map[string][]string{
+	"0710": {"PREVIOUSLY_WHITELISTED"},
+	"0720": {"3DS_MERCHANT_RESPONSE"},
+}
+
+// indexed response keys, such as RULE_ID_0, valid in every version
+var indexedFields = regexp.MustCompile(`^(RULE_ID|RULE_DESCRIPTION|COUNTER_NAME|COUNTER_VALUE|WARNING|ERROR|KC_WARNING|KC_ERROR)_\d+$|^KC_EVENT_\d+_(DECISION|EXPRESSION|CODE)$`)
+
+var (
+	fieldsMu      sync.RWMutex
+	versionFields = buildVersionFields()
+
+	unknownMu       sync.Mutex
+	unknownHandler  func(version string, keys []string)
+	reportedUnknown = make(map[unknownField]bool)
+)
+
+// an undocumented key as reported for one RIS version
+type unknownField struct {
+	version, key string
+}
+
+/**
+ * DocumentedVersions returns the RIS versions this SDK documents: 0700 and
+ * every version in fieldsAdded, oldest first. Versions created by
+ * RegisterFields are not included.
+ */
+func DocumentedVersions() []string {
+	versions := []string{"0700"}
+	for version := range fieldsAdded {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func buildVersionFields() map[string]map[string]bool {
+	versions := map[string]map[string]bool{
+		"0700": keySet(fields0700),
+	}
+	previous := versions["0700"]
+	for _, version := range DocumentedVersions()[1:] {
+		set := make(map[string]bool, len(previous))
+		for key := range previous {
+			set[key] = true
+		}
+		for _, key := range fieldsAdded[version] {
+			set[key] = true
+		}
+		versions[version] = set
+		previous = set
+	}
+	return versions
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// Get the RIS versions with a documented key set, oldest first.
+func Versions() []string {
+	fieldsMu.RLock()
+	defer fieldsMu.RUnlock()
+	versions := make([]string, 0, len(versionFields))
+	for version := range versionFields {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+/**
+ * RegisterFields adds keys to the documented key set of a version, creating
+ * the version if needed, so keys the SDK does not know yet can be accepted
+ * without waiting for a release.
+ */
+func RegisterFields(version string, keys ...string) {
+	fieldsMu.Lock()
+	defer fieldsMu.Unlock()
+	set, ok := versionFields[version]
+	if !ok {
+		set = make(map[string]bool)
+		versionFields[version] = set
+	}
+	for _, key := range keys {
+		set[key] = true
+	}
+}
+
+/**
+ * IsKnownField reports whether key is documented for the RIS version. An
+ * unknown version is checked against the newest known version.
+ */
+func IsKnownField(version, key string) bool {
+	if indexedFields.MatchString(key) {
+		return true
+	}
+	fieldsMu.RLock()
+	defer fieldsMu.RUnlock()
+	set, ok := versionFields[version]
+	if !ok {
+		newest := ""
+		for v := range versionFields {
+			if v > newest {
+				newest = v
+			}
+		}
+		set = versionFields[newest]
+	}
+	return set[key]
+}
+
+// Get the RIS version of the response, DefaultVersion if VERS is missing.
+func (r *Response) fieldsVersion() string {
+	if version := r.GetVersion(); version != "" {
+		return version
+	}
+	return DefaultVersion
+}
+
+// Get the sorted response keys that are not documented for the response's
+// RIS version.
+func (r *Response) UnknownFields() []string {
+	version := r.fieldsVersion()
+	var unknown []string
+	for key := range r.Data {
+		if !IsKnownField(version, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+/**
+ * SetUnknownFieldsHandler registers a function Digest calls with the response
+ * version and the keys that are undocumented for that version, e.g. to log or
+ * count new RIS fields. Each key is reported once per version to a handler;
+ * setting a handler starts over, so a new handler sees every key again. A nil
+ * handler turns reporting off.
+ */
+func SetUnknownFieldsHandler(handler func(version string, keys []string)) {
+	unknownMu.Lock()
+	unknownHandler = handler
+	reportedUnknown = make(map[unknownField]bool)
+	unknownMu.Unlock()
+}
+
+// Pass keys not reported before to the unknown fields handler.
+func (r *Response) reportUnknownFields() {
+	unknownMu.Lock()
+	handler := unknownHandler
+	if handler == nil {
+		unknownMu.Unlock()
+		return
+	}
+	version := r.fieldsVersion()
+	var fresh []string
+	for _, key := range r.UnknownFields() {
+		field := unknownField{version, key}
+		if !reportedUnknown[field] {
+			reportedUnknown[field] = true
+			fresh = append(fresh, key)
+		}
+	}
+	unknownMu.Unlock()
+
+	if len(fresh) > 0 {
+		handler(version, fresh)
+	}
+}
@@ -0,0 +1,100 @@
+package response
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFields(t *testing.T) {
+	tests := []struct {
+		data map[string]string
+		want []string
+	}{
+		{map[string]string{"VERS": "0700", "AUTO": "A", "RULE_ID_3": "1", "KC_EVENT_2_CODE": "x"}, nil},
+		{map[string]string{"VERS": "0700", "PREVIOUSLY_WHITELISTED": "N"}, []string{"PREVIOUSLY_WHITELISTED"}},
+		{map[string]string{"VERS": "0710", "PREVIOUSLY_WHITELISTED": "N", "3DS_MERCHANT_RESPONSE": "1"}, []string{"3DS_MERCHANT_RESPONSE"}},
+		{map[string]string{"VERS": "0720", "PREVIOUSLY_WHITELISTED": "N", "3DS_MERCHANT_RESPONSE": "1"}, nil},
+		// a missing version is the default version, an unknown one the newest
+		{map[string]string{"3DS_MERCHANT_RESPONSE": "1"}, []string{"3DS_MERCHANT_RESPONSE"}},
+		{map[string]string{"VERS": "0999", "3DS_MERCHANT_RESPONSE": "1", "ZZ_NEW": "1", "AA_NEW": "2"}, []string{"AA_NEW", "ZZ_NEW"}},
+		{map[string]string{"VERS": "0700", "RULE_ID_X": "1", "KC_EVENT_1_OTHER": "x"}, []string{"KC_EVENT_1_OTHER", "RULE_ID_X"}},
+	}
+	for _, test := range tests {
+		resp := &Response{Data: test.data}
+		if got := resp.UnknownFields(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.data, got, test.want)
+		}
+	}
+}
+
+func TestRegisterFields(t *testing.T) {
+	fieldsMu.Lock()
+	saved := versionFields
+	versionFields = buildVersionFields()
+	fieldsMu.Unlock()
+	t.Cleanup(func() {
+		fieldsMu.Lock()
+		versionFields = saved
+		fieldsMu.Unlock()
+	})
+
+	resp := &Response{Data: map[string]string{"VERS": "0700", "TEST_REGISTERED_FIELD": "1"}}
+	if got := resp.UnknownFields(); len(got) != 1 {
+		t.Fatalf("got %v before registering", got)
+	}
+	RegisterFields("0700", "TEST_REGISTERED_FIELD")
+	if got := resp.UnknownFields(); len(got) != 0 {
+		t.Errorf("got %v after registering", got)
+	}
+	if IsKnownField("0720", "TEST_REGISTERED_FIELD") {
+		t.Error("a field registered for 0700 is known for 0720")
+	}
+
+	RegisterFields("0990", "TEST_NEW_VERSION_FIELD")
+	if !IsKnownField("0990", "TEST_NEW_VERSION_FIELD") || IsKnownField("0990", "AUTO") {
+		t.Error("a registered version does not have exactly its registered fields")
+	}
+	if versions := Versions(); versions[len(versions)-1] != "0990" {
+		t.Errorf("versions %v do not include the registered one", versions)
+	}
+}
+
+func TestUnknownFieldsHandler(t *testing.T) {
+	type report struct {
+		version string
+		keys    []string
+	}
+	var reports []report
+	SetUnknownFieldsHandler(func(version string, keys []string) {
+		reports = append(reports, report{version, keys})
+	})
+	t.Cleanup(func() { SetUnknownFieldsHandler(nil) })
+
+	digest := func(raw string) {
+		resp := &Response{Raw: raw}
+		if err := resp.Digest(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	digest("VERS=0700\nAUTO=A\nTEST_HANDLER_FIELD=1\n")
+	digest("VERS=0700\nTEST_HANDLER_FIELD=2\n")
+	digest("AUTO=A\nTEST_HANDLER_FIELD=3\n")
+	digest("VERS=0710\nTEST_HANDLER_FIELD=4\n3DS_MERCHANT_RESPONSE=1\n")
+	want := []report{
+		{"0700", []string{"TEST_HANDLER_FIELD"}},
+		{"0710", []string{"3DS_MERCHANT_RESPONSE", "TEST_HANDLER_FIELD"}},
+	}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("got %v, want %v", reports, want)
+	}
+
+	// a new handler hears about every key again
+	reports = nil
+	SetUnknownFieldsHandler(func(version string, keys []string) {
+		reports = append(reports, report{version, keys})
+	})
+	digest("VERS=0700\nTEST_HANDLER_FIELD=5\n")
+	if len(reports) != 1 || reports[0].keys[0] != "TEST_HANDLER_FIELD" {
+		t.Errorf("new handler got %v", reports)
+	}
+}
@@ -0,0 +1,214 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Response formats requested with the FRMT parameter. Key=value lines are
+// the default when FRMT is not sent.
+const (
+	FormatKeyValue = ""
+	FormatJSON     = "JSON"
+	FormatXML      = "XML"
+)
+
+// DetectFormat guesses the format of a raw RIS response from its first
+// non-blank character.
+func DetectFormat(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		return FormatJSON
+	case strings.HasPrefix(trimmed, "<"):
+		return FormatXML
+	}
+	return FormatKeyValue
+}
+
+// indexedList describes how the elements of a repeated RIS field are
+// numbered in the key=value format.
+type indexedList struct {
+	first      int    // index of the first element
+	fieldFirst bool   // RULE_ID_0 rather than KC_EVENT_1_CODE
+	count      string // key holding the number of elements
+}
+
+// the repeated fields of a RIS response, by their name in JSON and XML
+var indexedLists = map[string]indexedList{
+	"RULE":       {0, true, "RULES_TRIGGERED"},
+	"COUNTER":    {0, true, "COUNTERS_TRIGGERED"},
+	"WARNING":    {0, true, "WARNING_COUNT"},
+	"ERROR":      {0, true, "ERROR_COUNT"},
+	"KC_WARNING": {1, true, "KC_WARNING_COUNT"},
+	"KC_ERROR":   {1, true, "KC_ERROR_COUNT"},
+	"KC_EVENT":   {1, false, "KC_TRIGGERED_COUNT"},
+}
+
+/**
+ * ParseJSON reads a JSON RIS response into the same flat key set as the
+ * key=value format. Nested objects are flattened by joining keys with '_'.
+ * Arrays are numbered the way the key=value format numbers them, e.g.
+ * {"RULE":[{"ID":"1"}]} becomes RULE_ID_0=1 and {"KC_EVENT":[{"CODE":"x"}]}
+ * becomes KC_EVENT_1_CODE=x, and the matching count such as RULES_TRIGGERED
+ * is set unless the body has it. Numbers keep their literal text.
+ */
+func ParseJSON(raw string) (map[string]string, error) {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return map[string]string{}, fmt.Errorf("response: %v", err)
+	}
+
+	data := make(map[string]string)
+	for key, value := range body {
+		flatten(data, key, value)
+	}
+	return data, nil
+}
+
+func flatten(data map[string]string, key string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+	case string:
+		data[key] = v
+	case json.Number:
+		data[key] = v.String()
+	case bool:
+		if v {
+			data[key] = "Y"
+		} else {
+			data[key] = "N"
+		}
+	case map[string]interface{}:
+		for k, nested := range v {
+			flatten(data, key+"_"+k, nested)
+		}
+	case []interface{}:
+		list, ok := indexedLists[key]
+		if !ok {
+			list = indexedList{fieldFirst: true}
+		}
+		for i, element := range v {
+			n := strconv.Itoa(list.first + i)
+			fields, ok := element.(map[string]interface{})
+			if !ok {
+				flatten(data, key+"_"+n, element)
+				continue
+			}
+			for field, nested := range fields {
+				if list.fieldFirst {
+					flatten(data, key+"_"+field+"_"+n, nested)
+				} else {
+					flatten(data, key+"_"+n+"_"+field, nested)
+				}
+			}
+		}
+		if _, ok := data[list.count]; list.count != "" && !ok {
+			data[list.count] = strconv.Itoa(len(v))
+		}
+	}
+}
+
+// an XML element with its text or child elements
+type xmlNode struct {
+	name     string
+	line     int
+	text     bytes.Buffer
+	children []*xmlNode
+}
+
+/**
+ * ParseXML reads an XML RIS response. Each child element of the root becomes
+ * a key holding the element's text, e.g. <RIS><AUTO>A</AUTO></RIS> becomes
+ * AUTO=A. Deeper elements are flattened like ParseJSON, with repeated fields
+ * such as <RULE><ID>1</ID></RULE> numbered like their JSON arrays. Other
+ * repeated elements are reported like duplicate keys in Parse.
+ */
+func ParseXML(raw string) (map[string]string, error) {
+	data := make(map[string]string)
+	var errs ParseErrors
+
+	decoder := xml.NewDecoder(strings.NewReader(raw))
+	var root *xmlNode
+	var open []*xmlNode
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, _ := decoder.InputPos()
+			errs = append(errs, &ParseError{Line: line, Reason: err.Error()})
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			line, _ := decoder.InputPos()
+			node := &xmlNode{name: t.Name.Local, line: line}
+			if len(open) > 0 {
+				parent := open[len(open)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			open = append(open, node)
+		case xml.CharData:
+			if len(open) > 0 {
+				open[len(open)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			open = open[:len(open)-1]
+		}
+	}
+
+	if root != nil {
+		body, bodyErrs := xmlObject(root)
+		errs = append(errs, bodyErrs...)
+		for key, value := range body {
+			flatten(data, key, value)
+		}
+	}
+
+	if len(errs) > 0 {
+		return data, errs
+	}
+	return data, nil
+}
+
+// Convert the children of an element into the shape ParseJSON decodes.
+func xmlObject(node *xmlNode) (map[string]interface{}, ParseErrors) {
+	object := make(map[string]interface{})
+	var errs ParseErrors
+
+	for _, child := range node.children {
+		var value interface{} = strings.TrimSpace(child.text.String())
+		if len(child.children) > 0 {
+			nested, nestedErrs := xmlObject(child)
+			value = nested
+			errs = append(errs, nestedErrs...)
+		}
+
+		key := child.name
+		if _, ok := indexedLists[key]; ok {
+			list, _ := object[key].([]interface{})
+			object[key] = append(list, value)
+			continue
+		}
+		if _, ok := object[key]; ok {
+			errs = append(errs, &ParseError{Line: child.line, Key: key, Reason: "duplicate key"})
+			continue
+		}
+		object[key] = value
+	}
+	return object, errs
+}
@@ -0,0 +1,57 @@
+package response
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNestedFormatsMatchKeyValue(t *testing.T) {
+	bodies := map[string]string{
+		FormatKeyValue: "AUTO=R\nRULES_TRIGGERED=2\nRULE_ID_0=10\nRULE_DESCRIPTION_0=a=b\nRULE_ID_1=20\nRULE_DESCRIPTION_1=c\n" +
+			"COUNTERS_TRIGGERED=1\nCOUNTER_NAME_0=ORDERS\nCOUNTER_VALUE_0=3\n" +
+			"KC_TRIGGERED_COUNT=1\nKC_EVENT_1_DECISION=D\nKC_EVENT_1_EXPRESSION=SCOR>5\nKC_EVENT_1_CODE=big\n",
+		FormatJSON: `{"AUTO":"R",
+			"RULE":[{"ID":"10","DESCRIPTION":"a=b"},{"ID":20,"DESCRIPTION":"c"}],
+			"COUNTER":[{"NAME":"ORDERS","VALUE":3}],
+			"KC_EVENT":[{"DECISION":"D","EXPRESSION":"SCOR>5","CODE":"big"}]}`,
+		FormatXML: `<RIS><AUTO>R</AUTO>
+			<RULE><ID>10</ID><DESCRIPTION>a=b</DESCRIPTION></RULE>
+			<RULE><ID>20</ID><DESCRIPTION>c</DESCRIPTION></RULE>
+			<COUNTER><NAME>ORDERS</NAME><VALUE>3</VALUE></COUNTER>
+			<KC_EVENT><DECISION>D</DECISION><EXPRESSION>SCOR&gt;5</EXPRESSION><CODE>big</CODE></KC_EVENT>
+			</RIS>`,
+	}
+
+	var want *Response
+	for _, format := range []string{FormatKeyValue, FormatJSON, FormatXML} {
+		resp := &Response{Raw: bodies[format], Format: format}
+		if err := resp.Digest(); err != nil {
+			t.Fatalf("%q: %v", format, err)
+		}
+		if want == nil {
+			want = resp
+			continue
+		}
+		if !reflect.DeepEqual(resp.Rules(), want.Rules()) {
+			t.Errorf("%q: rules %v, want %v", format, resp.Rules(), want.Rules())
+		}
+		counters, err := resp.Counters()
+		wantCounters, _ := want.Counters()
+		if err != nil || !reflect.DeepEqual(counters, wantCounters) {
+			t.Errorf("%q: counters %v, %v; want %v", format, counters, err, wantCounters)
+		}
+		if !reflect.DeepEqual(resp.GetKCEvents(), want.GetKCEvents()) {
+			t.Errorf("%q: KC events %v, want %v", format, resp.GetKCEvents(), want.GetKCEvents())
+		}
+	}
+	if len(want.Rules()) != 2 || len(want.GetKCEvents()) != 1 {
+		t.Fatalf("key=value body parsed to %v and %v", want.Rules(), want.GetKCEvents())
+	}
+}
+
+func TestParseXMLReportsDuplicateKeys(t *testing.T) {
+	data, err := ParseXML("<RIS><AUTO>A</AUTO><AUTO>D</AUTO></RIS>")
+	if _, ok := err.(ParseErrors); !ok || data["AUTO"] != "A" {
+		t.Fatalf("got %v, %v", data, err)
+	}
+}
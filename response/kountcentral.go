@@ -0,0 +1,63 @@
+package response
+
+// KCEvent is a Kount Central threshold event with a typed decision.
+type KCEvent struct {
+	Decision   Decision
+	Expression string
+	Code       string
+}
+
+// KountCentralResult gathers the Kount Central parts of a response.
+type KountCentralResult struct {
+	CustomerID string
+	Events     []KCEvent
+	Warnings   []Warning
+	Errors     []Warning
+}
+
+// Get the Kount Central result of the response.
+func (r *Response) KountCentral() KountCentralResult {
+	result := KountCentralResult{
+		CustomerID: r.GetKCCustomerId(),
+		Warnings:   r.KCWarningDetails(),
+		Errors:     r.KCErrorDetails(),
+	}
+	for _, event := range r.GetKCEvents() {
+		result.Events = append(result.Events, KCEvent{
+			Decision:   Decision(event.Decision),
+			Expression: event.Expression,
+			Code:       event.Code,
+		})
+	}
+	return result
+}
+
+/**
+ * Get the most severe threshold event, decline before escalate before review
+ * before approve. The first event wins ties. ok is false without events.
+ */
+func (k KountCentralResult) MostSevereEvent() (event KCEvent, ok bool) {
+	for i, e := range k.Events {
+		if i == 0 || e.Decision.Severity() > event.Decision.Severity() {
+			event = e
+		}
+	}
+	return event, len(k.Events) > 0
+}
+
+/**
+ * Get the overall Kount Central decision: the decision of the most severe
+ * threshold event, or DecisionApprove when no threshold was triggered.
+ */
+func (k KountCentralResult) Decision() Decision {
+	event, ok := k.MostSevereEvent()
+	if !ok {
+		return DecisionApprove
+	}
+	return event.Decision
+}
+
+// Report whether Kount Central returned any errors.
+func (k KountCentralResult) HasErrors() bool {
+	return len(k.Errors) > 0
+}
@@ -0,0 +1,77 @@
+package response
+
+import "testing"
+
+func TestKountCentral(t *testing.T) {
+	resp := &Response{Data: map[string]string{
+		"KC_CUSTOMER_ID":        "gateway-7",
+		"KC_TRIGGERED_COUNT":    "3",
+		"KC_EVENT_1_DECISION":   "R",
+		"KC_EVENT_1_EXPRESSION": "SCOR>40",
+		"KC_EVENT_1_CODE":       "review-score",
+		"KC_EVENT_2_DECISION":   "D",
+		"KC_EVENT_2_EXPRESSION": "SCOR>80",
+		"KC_EVENT_2_CODE":       "decline-score",
+		"KC_EVENT_3_DECISION":   "D",
+		"KC_EVENT_3_EXPRESSION": "TOTL>100000",
+		"KC_EVENT_3_CODE":       "decline-total",
+		"KC_WARNING_COUNT":      "1",
+		"KC_WARNING_1":          "399 BAD_OPTN Field: [UDF], Value: [x]",
+		"KC_ERROR_COUNT":        "0",
+	}}
+
+	kc := resp.KountCentral()
+	if kc.CustomerID != "gateway-7" || len(kc.Events) != 3 || kc.HasErrors() {
+		t.Fatalf("got %+v", kc)
+	}
+	if kc.Events[0] != (KCEvent{Decision: DecisionReview, Expression: "SCOR>40", Code: "review-score"}) {
+		t.Errorf("first event %+v", kc.Events[0])
+	}
+	if len(kc.Warnings) != 1 || kc.Warnings[0].Code != 399 {
+		t.Errorf("warnings %+v", kc.Warnings)
+	}
+
+	// the first of equally severe events wins
+	event, ok := kc.MostSevereEvent()
+	if !ok || event.Code != "decline-score" {
+		t.Errorf("most severe event %+v, %v", event, ok)
+	}
+	if kc.Decision() != DecisionDecline {
+		t.Errorf("decision %s", kc.Decision())
+	}
+}
+
+func TestKountCentralDecision(t *testing.T) {
+	tests := []struct {
+		events []KCEvent
+		want   Decision
+	}{
+		{nil, DecisionApprove},
+		{[]KCEvent{{Decision: DecisionApprove}}, DecisionApprove},
+		{[]KCEvent{{Decision: DecisionReview}, {Decision: DecisionApprove}}, DecisionReview},
+		{[]KCEvent{{Decision: DecisionEscalate}, {Decision: DecisionReview}}, DecisionEscalate},
+		{[]KCEvent{{Decision: DecisionEscalate}, {Decision: DecisionDecline}}, DecisionDecline},
+	}
+	for _, test := range tests {
+		kc := KountCentralResult{Events: test.events}
+		if got := kc.Decision(); got != test.want {
+			t.Errorf("%v: got %s, want %s", test.events, got, test.want)
+		}
+	}
+	if _, ok := (KountCentralResult{}).MostSevereEvent(); ok {
+		t.Error("MostSevereEvent without events: want ok false")
+	}
+}
+
+func TestKCGettersAreNumberedFromOne(t *testing.T) {
+	resp := &Response{Data: map[string]string{
+		"KC_ERROR_COUNT": "2",
+		"KC_ERROR_0":     "not sent by RIS",
+		"KC_ERROR_1":     "first",
+		"KC_ERROR_2":     "second",
+	}}
+	errors := resp.GetKCErrors()
+	if len(errors) != 2 || errors[0] != "first" || errors[1] != "second" {
+		t.Errorf("got %q", errors)
+	}
+}
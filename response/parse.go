@@ -0,0 +1,72 @@
+package response
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError describes a line of a RIS response that could not be parsed.
+type ParseError struct {
+	Line   int    // 1-based line number
+	Key    string // the key, if the line had one
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("response: line %d: %s %q", e.Line, e.Reason, e.Key)
+	}
+	return fmt.Sprintf("response: line %d: %s", e.Line, e.Reason)
+}
+
+// ParseErrors lists every line that could not be parsed.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+/**
+ * Parse reads a key=value RIS response. Each line is split on its first '=',
+ * so values may contain '=' themselves, and both LF and CRLF line endings are
+ * accepted. Blank lines are skipped. Lines without a key and repeated keys are
+ * reported in a ParseErrors; the first value of a repeated key is kept and all
+ * well formed lines are returned even when there are errors.
+ */
+func Parse(raw string) (map[string]string, error) {
+	data := make(map[string]string)
+	var errs ParseErrors
+
+	for i, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		sep := strings.Index(line, "=")
+		switch {
+		case sep < 0:
+			errs = append(errs, &ParseError{Line: i + 1, Reason: "missing '='"})
+			continue
+		case sep == 0:
+			errs = append(errs, &ParseError{Line: i + 1, Reason: "missing key"})
+			continue
+		}
+
+		key, value := line[:sep], line[sep+1:]
+		if _, ok := data[key]; ok {
+			errs = append(errs, &ParseError{Line: i + 1, Key: key, Reason: "duplicate key"})
+			continue
+		}
+		data[key] = value
+	}
+
+	if len(errs) > 0 {
+		return data, errs
+	}
+	return data, nil
+}
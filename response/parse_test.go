@@ -0,0 +1,54 @@
+package response
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"MODE=Q\nAUTO=A\nSCOR=34\n",
+		"RULE_DESCRIPTION_0=Velocity > 2 = review\nUAS=Mozilla/5.0 (a=b; c=d)\n",
+		"MODE=Q\r\nAUTO=R\r\nURL=https://example.com/?a=1&b=2\r\n",
+		"MODE=Q\n\n\r\n   \nAUTO=A\n",
+		"=no key\nMODE=Q\n",
+		"no separator\n",
+		"AUTO=A\nAUTO=D\n",
+		"EMPTY=\n==\n",
+		"",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		data, _ := Parse(raw)
+
+		seen := make(map[string]bool)
+		for _, line := range strings.Split(raw, "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			key, value, ok := strings.Cut(line, "=")
+			if !ok || key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if got, ok := data[key]; !ok || got != value {
+				t.Fatalf("line %q: got %q, %v; want %q", line, got, ok, value)
+			}
+		}
+		if len(data) != len(seen) {
+			t.Fatalf("got %d keys, want %d", len(data), len(seen))
+		}
+	})
+}
+
+func TestParseReportsMalformedLines(t *testing.T) {
+	data, err := Parse("MODE=Q\r\n=x\nbad\nMODE=P\nURL=a=b\n")
+	errs, ok := err.(ParseErrors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("got %v, want 3 parse errors", err)
+	}
+	if data["MODE"] != "Q" || data["URL"] != "a=b" {
+		t.Fatalf("got %v", data)
+	}
+}
@@ -0,0 +1,44 @@
+package response
+
+import "strings"
+
+// Redacted replaces the value of a redacted field.
+const Redacted = "REDACTED"
+
+/**
+ * Request fields that carry payment or personal data. A field ending in '*'
+ * covers every key with that prefix; user defined fields are all included
+ * since they may carry personal data, such as the canonical email address.
+ * Response.Request and cassette recordings both redact these fields.
+ */
+var RedactedRequestFields = []string{
+	"PTOK", "EMAL", "NAME", "ANID", "DOB", "IPAD", "UAGT", "UNIQ",
+	"B2A1", "B2A2", "B2CI", "B2ST", "B2PC", "B2PN",
+	"S2A1", "S2A2", "S2CI", "S2ST", "S2PC", "S2PN", "S2NM", "S2EM",
+	"BPREMISE", "BSTREET", "SPREMISE", "SSTREET", "UDF[*",
+}
+
+// Report whether key is one of fields or has the prefix of a field ending
+// in '*'.
+func FieldMatches(fields []string, key string) bool {
+	for _, field := range fields {
+		if field == key || strings.HasSuffix(field, "*") && strings.HasPrefix(key, strings.TrimSuffix(field, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Copy params with the value of every key matching fields replaced by
+// Redacted. Empty values are kept, so a redacted copy still shows which
+// fields were left blank.
+func RedactFields(params map[string]string, fields []string) map[string]string {
+	redacted := make(map[string]string, len(params))
+	for key, value := range params {
+		if value != "" && FieldMatches(fields, key) {
+			value = Redacted
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
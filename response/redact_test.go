@@ -0,0 +1,51 @@
+package response
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactFields(t *testing.T) {
+	params := map[string]string{
+		"MODE":           "Q",
+		"PTOK":           "4111111111111111",
+		"EMAL":           "john@example.com",
+		"B2A1":           "1 Main St",
+		"B2A2":           "",
+		"B2CC":           "US",
+		"UDF[CANONICAL]": "john@example.com",
+		"TOTL":           "1000",
+	}
+	got := RedactFields(params, RedactedRequestFields)
+	want := map[string]string{
+		"MODE":           "Q",
+		"PTOK":           Redacted,
+		"EMAL":           Redacted,
+		"B2A1":           Redacted,
+		"B2A2":           "",
+		"B2CC":           "US",
+		"UDF[CANONICAL]": Redacted,
+		"TOTL":           "1000",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if params["EMAL"] != "john@example.com" {
+		t.Error("RedactFields changed its input")
+	}
+}
+
+func TestFieldMatches(t *testing.T) {
+	fields := []string{"EMAL", "UDF[*"}
+	for key, want := range map[string]bool{
+		"EMAL":       true,
+		"EMAL2":      false,
+		"UDF[COLOR]": true,
+		"UDF":        false,
+		"S2EM":       false,
+	} {
+		if got := FieldMatches(fields, key); got != want {
+			t.Errorf("FieldMatches(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
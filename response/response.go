@@ -3,29 +3,41 @@ package response
 import (
 	"github.com/phpsquid/kount/data"
 	"strconv"
-	"strings"
+	"time"
 )
 
 type Response struct {
-	Raw  string            // Raw response string
-	Data map[string]string // a map containing extracted response body values
+	Raw        string            // Raw response string
+	Data       map[string]string // a map containing extracted response body values
+	Format     string            // format of Raw: FormatKeyValue, FormatJSON or FormatXML
+	ReceivedAt time.Time         // when the response was received
+	Latency    time.Duration     // time between sending the request and reading the response
+	Request    map[string]string // parameters of the request this response answered, redacted
 }
 
-// Digest parses the raw response string and updates Data map[string]string
-func (r *Response) Digest() {
-	data := make(map[string]string)
-	r.Data = data
-
-	// split raw response on new line character
-	splitRaw := strings.Split(r.Raw, "\n")
+/**
+ * Digest parses the raw response string and updates Data map[string]string.
+ * The format is detected from Raw unless Format is already set. Data holds
+ * every value that could be read even when an error is returned.
+ */
+func (r *Response) Digest() error {
+	if r.Format == FormatKeyValue {
+		r.Format = DetectFormat(r.Raw)
+	}
 
-	// loop over the new []string and update Data map[string]string
-	for _, v := range splitRaw {
-		splitValue := strings.Split(v, "=")
-		if len(splitValue) == 2 {
-			r.Data[splitValue[0]] = splitValue[1]
-		}
+	var data map[string]string
+	var err error
+	switch r.Format {
+	case FormatJSON:
+		data, err = ParseJSON(r.Raw)
+	case FormatXML:
+		data, err = ParseXML(r.Raw)
+	default:
+		data, err = Parse(r.Raw)
 	}
+	r.Data = data
+	r.reportUnknownFields()
+	return err
 }
 
 // Get an explicit parameter from the response
@@ -73,11 +85,16 @@ func (r *Response) GetOrderNumber() string {
 	return r.GetParam("ORDR")
 }
 
-// Get the RIS auto response (A/R/D)
+// Get the RIS auto response (A/R/D/E)
 func (r *Response) GetAuto() string {
 	return r.GetParam("AUTO")
 }
 
+// Get the RIS auto response as a Decision
+func (r *Response) GetDecision() Decision {
+	return Decision(r.GetAuto())
+}
+
 // Get the merchant defined decision reason code.
 func (r *Response) GetReasonCode() string {
 	return r.GetParam("REASON_CODE")
@@ -375,7 +392,7 @@ func (r *Response) GetNumberRulesTriggered() int {
 
 // Get a string slice of the warnings associated with this response.
 func (r *Response) GetWarnings() []string {
-	warningCount := r.GetWarningCount()
+	warningCount := r.listLength(r.GetWarningCount())
 	var warnings []string
 
 	for i := 0; i < warningCount; i++ {
@@ -397,7 +414,7 @@ func (r *Response) GetWarningCount() int {
 
 // Get a string slice of the errors associated with this response.
 func (r *Response) GetErrors() []string {
-	errorCount := r.GetErrorCount()
+	errorCount := r.listLength(r.GetErrorCount())
 	var errors []string
 
 	for i := 0; i < errorCount; i++ {
@@ -441,10 +458,10 @@ func (r *Response) GetNumberCountersTriggered() int {
 
 // Get a string slice of the Kount Central warnings associated with the response.
 func (r *Response) GetKCWarnings() []string {
-	warningCount := r.GetKCWarningCount()
+	warningCount := r.listLength(r.GetKCWarningCount())
 	var warnings []string
 
-	for i := 0; i < warningCount; i++ {
+	for i := 1; i <= warningCount; i++ {
 		warning := r.GetParam("KC_WARNING_" + strconv.Itoa(i))
 		warnings = append(warnings, warning)
 	}
@@ -463,10 +480,10 @@ func (r *Response) GetKCWarningCount() int {
 
 // Get a string slice of the Kount Central warnings associated with the response.
 func (r *Response) GetKCErrors() []string {
-	errorCount := r.GetKCErrorCount()
+	errorCount := r.listLength(r.GetKCErrorCount())
 	var errors []string
 
-	for i := 0; i < errorCount; i++ {
+	for i := 1; i <= errorCount; i++ {
 		warning := r.GetParam("KC_ERROR_" + strconv.Itoa(i))
 		errors = append(errors, warning)
 	}
@@ -485,10 +502,10 @@ func (r *Response) GetKCErrorCount() int {
 
 // Get the Kount Central threshold events associated with the decision
 func (r *Response) GetKCEvents() []data.KCEvent {
-	eventCount := r.GetKCEventCount()
+	eventCount := r.listLength(r.GetKCEventCount())
 	var events []data.KCEvent
 
-	for i := 0; i < eventCount; i++ {
+	for i := 1; i <= eventCount; i++ {
 		decision := r.GetParam("KC_EVENT_" + strconv.Itoa(i) + "_DECISION")
 		expression := r.GetParam("KC_EVENT_" + strconv.Itoa(i) + "_EXPRESSION")
 		code := r.GetParam("KC_EVENT_" + strconv.Itoa(i) + "_CODE")
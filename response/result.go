@@ -0,0 +1,273 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decision is the RIS auto decision returned in AUTO.
+type Decision string
+
+const (
+	DecisionApprove  Decision = "A"
+	DecisionReview   Decision = "R"
+	DecisionDecline  Decision = "D"
+	DecisionEscalate Decision = "E"
+)
+
+func (d Decision) String() string {
+	switch d {
+	case DecisionApprove:
+		return "approve"
+	case DecisionReview:
+		return "review"
+	case DecisionDecline:
+		return "decline"
+	case DecisionEscalate:
+		return "escalate"
+	}
+	return "unknown(" + string(d) + ")"
+}
+
+/**
+ * Severity orders decisions from approve (lowest) to decline (highest), so the
+ * most severe of several decisions can be picked. Unknown decisions are 0.
+ */
+func (d Decision) Severity() int {
+	switch d {
+	case DecisionApprove:
+		return 1
+	case DecisionReview:
+		return 2
+	case DecisionEscalate:
+		return 3
+	case DecisionDecline:
+		return 4
+	}
+	return 0
+}
+
+// Report whether d is one of the decisions RIS returns.
+func (d Decision) Valid() bool {
+	switch d {
+	case DecisionApprove, DecisionReview, DecisionDecline, DecisionEscalate:
+		return true
+	}
+	return false
+}
+
+// IPInfo holds the geolocation of an IP address. Its ris tags are appended
+// to the IP_ or PIP_ prefix of the enclosing field.
+type IPInfo struct {
+	Address      string  `ris:"IPAD"`
+	Latitude     float64 `ris:"LAT"`
+	Longitude    float64 `ris:"LON"`
+	Country      string  `ris:"COUNTRY"`
+	Region       string  `ris:"REGION"`
+	City         string  `ris:"CITY"`
+	Organization string  `ris:"ORG"`
+}
+
+/**
+ * RISResult is a typed view of a RIS response. The ris tag of each field
+ * names the response key it is read from. Keys missing from the response
+ * leave the zero value.
+ */
+type RISResult struct {
+	Version           string    `ris:"VERS"`
+	Mode              string    `ris:"MODE"`
+	TransactionID     string    `ris:"TRAN"`
+	MerchantID        string    `ris:"MERC"`
+	KCCustomerID      string    `ris:"KC_CUSTOMER_ID"`
+	SessionID         string    `ris:"SESS"`
+	Site              string    `ris:"SITE"`
+	OrderNumber       string    `ris:"ORDR"`
+	Auto              Decision  `ris:"AUTO"`
+	ReasonCode        string    `ris:"REASON_CODE"`
+	Score             int       `ris:"SCOR"`
+	Omniscore         float64   `ris:"OMNISCORE"`
+	Geox              string    `ris:"GEOX"`
+	Brand             string    `ris:"BRND"`
+	Velocity          int       `ris:"VELO"`
+	VelocityMax       int       `ris:"VMAX"`
+	Network           string    `ris:"NETW"`
+	KnowYourCustomer  bool      `ris:"KYCF"`
+	Region            string    `ris:"REGN"`
+	Kaptcha           bool      `ris:"KAPT"`
+	Proxy             bool      `ris:"PROXY"`
+	Emails            int       `ris:"EMAILS"`
+	HTTPCountry       string    `ris:"HTTP_COUNTRY"`
+	TimeZone          int       `ris:"TIMEZONE"`
+	Cards             int       `ris:"CARDS"`
+	PCRemote          bool      `ris:"PC_REMOTE"`
+	Devices           int       `ris:"DEVICES"`
+	DeviceLayers      string    `ris:"DEVICE_LAYERS"`
+	MobileForwarder   bool      `ris:"MOBILE_FORWARDER"`
+	VoiceDevice       bool      `ris:"VOICE_DEVICE"`
+	LocalTime         time.Time `ris:"LOCALTIME"`
+	MobileType        string    `ris:"MOBILE_TYPE"`
+	Fingerprint       string    `ris:"FINGERPRINT"`
+	Flash             bool      `ris:"FLASH"`
+	Language          string    `ris:"LANGUAGE"`
+	Country           string    `ris:"COUNTRY"`
+	JavaScript        bool      `ris:"JAVASCRIPT"`
+	Cookies           bool      `ris:"COOKIES"`
+	MobileDevice      bool      `ris:"MOBILE_DEVICE"`
+	MasterCardScore   int       `ris:"MASTERCARD"`
+	PiercedIP         IPInfo    `ris:"PIP_"`
+	ProxyIP           IPInfo    `ris:"IP_"`
+	DeviceFirstSeen   time.Time `ris:"DDFS"`
+	UserAgent         string    `ris:"UAS"`
+	ScreenResolution  string    `ris:"DSR"`
+	OS                string    `ris:"OS"`
+	Browser           string    `ris:"BROWSER"`
+	ErrorCode         string    `ris:"ERRO"`
+	RulesTriggered    int       `ris:"RULES_TRIGGERED"`
+	CountersTriggered int       `ris:"COUNTERS_TRIGGERED"`
+	WarningCount      int       `ris:"WARNING_COUNT"`
+	ErrorCount        int       `ris:"ERROR_COUNT"`
+}
+
+// layouts accepted for date and time fields
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC3339,
+}
+
+// FieldError describes a response value that could not be parsed.
+type FieldError struct {
+	Field string // RISResult field name
+	Key   string // response key
+	Value string // raw response value
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("response: %s (%s=%q): %v", e.Field, e.Key, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors lists every field that failed to parse.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+/**
+ * Unmarshal parses the response data into result. Every field is parsed even
+ * if others fail; the error, if any, is a FieldErrors listing each value that
+ * could not be parsed. Fields that failed keep their zero value.
+ */
+func (r *Response) Unmarshal(result *RISResult) error {
+	return r.decode(result)
+}
+
+// Decode the response into a struct whose fields carry ris tags.
+func (r *Response) decode(v interface{}) error {
+	errs := r.decodeStruct(reflect.ValueOf(v).Elem(), "", "")
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+/**
+ * Decode the fields of a struct. Nested structs, other than time.Time, use
+ * their field's tag as a key prefix for their own fields.
+ */
+func (r *Response) decodeStruct(v reflect.Value, prefix, path string) FieldErrors {
+	var errs FieldErrors
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + field.Tag.Get("ris")
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			errs = append(errs, r.decodeStruct(v.Field(i), key, path+field.Name+".")...)
+			continue
+		}
+		if err := r.setField(v.Field(i), key); err != nil {
+			errs = append(errs, &FieldError{path + field.Name, key, r.GetParam(key), err})
+		}
+	}
+	return errs
+}
+
+// Parse a response value into a struct field.
+func (r *Response) setField(field reflect.Value, key string) error {
+	value, ok := r.Data[key]
+	if !ok || value == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case Decision:
+		d := Decision(value)
+		if !d.Valid() {
+			return errors.New("unknown decision")
+		}
+		field.SetString(value)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case bool:
+		b, err := parseFlag(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case time.Time:
+		ts, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(ts))
+	}
+	return nil
+}
+
+// Parse a "Y"/"N" flag.
+func parseFlag(value string) (bool, error) {
+	switch value {
+	case "Y", "y":
+		return true, nil
+	case "N", "n":
+		return false, nil
+	}
+	return false, errors.New("expected Y or N")
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if ts, err := time.Parse(layout, value); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, errors.New("unrecognized date format")
+}
@@ -0,0 +1,121 @@
+package response
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalFixture(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/inquiry.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &Response{Raw: string(raw)}
+	resp.Digest()
+
+	var result RISResult
+	if err := resp.Unmarshal(&result); err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		field     string
+		got, want interface{}
+	}{
+		{"Version", result.Version, "0720"},
+		{"TransactionID", result.TransactionID, "PTPN0Z04P8Y6"},
+		{"Auto", result.Auto, DecisionReview},
+		{"ReasonCode", result.ReasonCode, ""},
+		{"Score", result.Score, 34},
+		{"Omniscore", result.Omniscore, 62.5},
+		{"VelocityMax", result.VelocityMax, 3},
+		{"Kaptcha", result.Kaptcha, true},
+		{"Proxy", result.Proxy, false},
+		{"TimeZone", result.TimeZone, 420},
+		{"LocalTime", result.LocalTime, time.Date(2024, 3, 14, 9, 26, 53, 0, time.UTC)},
+		{"DeviceFirstSeen", result.DeviceFirstSeen, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"MasterCardScore", result.MasterCardScore, 0},
+		{"PiercedIP.Address", result.PiercedIP.Address, "203.0.113.7"},
+		{"PiercedIP.Latitude", result.PiercedIP.Latitude, 47.6062},
+		{"PiercedIP.Longitude", result.PiercedIP.Longitude, -122.3321},
+		{"PiercedIP.City", result.PiercedIP.City, "Seattle"},
+		{"ProxyIP", result.ProxyIP, IPInfo{}},
+		{"UserAgent", result.UserAgent, "Mozilla/5.0 (Windows NT 10.0; Win64; x64)"},
+		{"RulesTriggered", result.RulesTriggered, 1},
+		{"ErrorCode", result.ErrorCode, ""},
+	}
+	for _, check := range checks {
+		if check.got != check.want {
+			t.Errorf("%s = %#v, want %#v", check.field, check.got, check.want)
+		}
+	}
+	if result.Auto.String() != "review" || result.Auto.Severity() != 2 {
+		t.Errorf("Auto = %v with severity %d", result.Auto, result.Auto.Severity())
+	}
+}
+
+func TestDecision(t *testing.T) {
+	tests := []struct {
+		decision Decision
+		name     string
+		severity int
+		valid    bool
+	}{
+		{DecisionApprove, "approve", 1, true},
+		{DecisionReview, "review", 2, true},
+		{DecisionEscalate, "escalate", 3, true},
+		{DecisionDecline, "decline", 4, true},
+		{Decision("X"), "unknown(X)", 0, false},
+		{Decision(""), "unknown()", 0, false},
+	}
+	for _, test := range tests {
+		if got := test.decision.String(); got != test.name {
+			t.Errorf("Decision(%q).String() = %q, want %q", string(test.decision), got, test.name)
+		}
+		if got := test.decision.Severity(); got != test.severity {
+			t.Errorf("Decision(%q).Severity() = %d, want %d", string(test.decision), got, test.severity)
+		}
+		if got := test.decision.Valid(); got != test.valid {
+			t.Errorf("Decision(%q).Valid() = %v, want %v", string(test.decision), got, test.valid)
+		}
+	}
+}
+
+func TestUnmarshalFieldErrors(t *testing.T) {
+	resp := &Response{Raw: "AUTO=Z\nSCOR=high\nOMNISCORE=62.5\nKAPT=maybe\nLOCALTIME=yesterday\nPIP_LAT=north\nGEOX=US\n"}
+	resp.Digest()
+
+	var result RISResult
+	err := resp.Unmarshal(&result)
+	var fieldErrs FieldErrors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("got %v, want FieldErrors", err)
+	}
+	want := map[string]string{
+		"Auto":               "AUTO",
+		"Score":              "SCOR",
+		"Kaptcha":            "KAPT",
+		"LocalTime":          "LOCALTIME",
+		"PiercedIP.Latitude": "PIP_LAT",
+	}
+	if len(fieldErrs) != len(want) {
+		t.Errorf("got %d field errors: %v", len(fieldErrs), err)
+	}
+	for _, fieldErr := range fieldErrs {
+		if key, ok := want[fieldErr.Field]; !ok || fieldErr.Key != key {
+			t.Errorf("unexpected field error %v", fieldErr)
+		}
+		if fieldErr.Value == "" || fieldErr.Err == nil {
+			t.Errorf("field error %v lacks its value or cause", fieldErr)
+		}
+	}
+
+	// fields that failed keep their zero value, the others are still parsed
+	if result.Auto != "" || result.Score != 0 || result.Kaptcha || !result.LocalTime.IsZero() {
+		t.Errorf("failed fields were set: %+v", result)
+	}
+	if result.Omniscore != 62.5 || result.Geox != "US" {
+		t.Errorf("valid fields not parsed: Omniscore=%v Geox=%q", result.Omniscore, result.Geox)
+	}
+}
@@ -0,0 +1,96 @@
+package response
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Rule is a rule triggered by the inquiry, in the order RIS reported it.
+type Rule struct {
+	Index       int
+	ID          string
+	Description string
+}
+
+// Counter is a rules counter triggered by the inquiry.
+type Counter struct {
+	Name  string
+	Value int
+}
+
+// Get the triggered rules in the order RIS reported them. Unlike
+// GetRulesTriggered, repeated rule ids are all kept.
+func (r *Response) Rules() []Rule {
+	count := r.listLength(r.GetNumberRulesTriggered())
+	var rules []Rule
+
+	for i := 0; i < count; i++ {
+		rules = append(rules, Rule{
+			Index:       i,
+			ID:          r.GetParam("RULE_ID_" + strconv.Itoa(i)),
+			Description: r.GetParam("RULE_DESCRIPTION_" + strconv.Itoa(i)),
+		})
+	}
+
+	return rules
+}
+
+// Clamp a count reported by RIS to the number of fields in the response, so
+// a negative or absurd count cannot panic or loop without bound.
+func (r *Response) listLength(count int) int {
+	if count < 0 {
+		return 0
+	}
+	if count > len(r.Data) {
+		return len(r.Data)
+	}
+	return count
+}
+
+// Report whether the rule with the given id was triggered.
+func (r *Response) HasRule(id string) bool {
+	for _, rule := range r.Rules() {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the triggered rules whose description starts with prefix.
+func (r *Response) RulesMatching(prefix string) []Rule {
+	var rules []Rule
+	for _, rule := range r.Rules() {
+		if strings.HasPrefix(rule.Description, prefix) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+/**
+ * Get the triggered rules counters in the order RIS reported them. Counters
+ * whose value is not a number are returned with a zero Value and listed in
+ * the FieldErrors error.
+ */
+func (r *Response) Counters() ([]Counter, error) {
+	count := r.listLength(r.GetNumberCountersTriggered())
+	var counters []Counter
+	var errs FieldErrors
+
+	for i := 0; i < count; i++ {
+		valueKey := "COUNTER_VALUE_" + strconv.Itoa(i)
+		counter := Counter{Name: r.GetParam("COUNTER_NAME_" + strconv.Itoa(i))}
+		value, err := strconv.Atoi(r.GetParam(valueKey))
+		if err != nil {
+			errs = append(errs, &FieldError{"Counter[" + strconv.Itoa(i) + "].Value", valueKey, r.GetParam(valueKey), err})
+		}
+		counter.Value = value
+		counters = append(counters, counter)
+	}
+
+	if len(errs) > 0 {
+		return counters, errs
+	}
+	return counters, nil
+}
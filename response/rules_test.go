@@ -0,0 +1,40 @@
+package response
+
+import "testing"
+
+func TestRulesIgnoreBadCounts(t *testing.T) {
+	for _, raw := range []string{"RULES_TRIGGERED=-1\nCOUNTERS_TRIGGERED=-2\n", "RULES_TRIGGERED=2000000000\nCOUNTERS_TRIGGERED=2000000000\n"} {
+		resp := &Response{Raw: raw}
+		resp.Digest()
+		if rules := resp.Rules(); len(rules) > 2 {
+			t.Errorf("%q: got %d rules", raw, len(rules))
+		}
+		if counters, _ := resp.Counters(); len(counters) > 2 {
+			t.Errorf("%q: got %d counters", raw, len(counters))
+		}
+	}
+}
+
+func TestListsIgnoreBadCounts(t *testing.T) {
+	for _, count := range []string{"-1", "2000000000"} {
+		resp := &Response{Data: map[string]string{
+			"WARNING_COUNT":      count,
+			"ERROR_COUNT":        count,
+			"KC_WARNING_COUNT":   count,
+			"KC_ERROR_COUNT":     count,
+			"KC_TRIGGERED_COUNT": count,
+		}}
+		lists := map[string]int{
+			"GetWarnings":   len(resp.GetWarnings()),
+			"GetErrors":     len(resp.GetErrors()),
+			"GetKCWarnings": len(resp.GetKCWarnings()),
+			"GetKCErrors":   len(resp.GetKCErrors()),
+			"GetKCEvents":   len(resp.GetKCEvents()),
+		}
+		for name, n := range lists {
+			if n > len(resp.Data) {
+				t.Errorf("count %s: %s returned %d entries", count, name, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// version of the JSON and binary encodings written by this package
+const serializationVersion = 1
+
+var binaryMagic = []byte("KRIS")
+
+var ErrUnsupportedEncoding = errors.New("response: unsupported encoding version")
+
+// the JSON form of a Response
+type responseJSON struct {
+	Version    int               `json:"version"`
+	Raw        string            `json:"raw"`
+	Format     string            `json:"format,omitempty"`
+	Data       map[string]string `json:"data"`
+	ReceivedAt time.Time         `json:"received_at"`
+	LatencyNS  int64             `json:"latency_ns"`
+	Request    map[string]string `json:"request"`
+}
+
+// MarshalJSON encodes the response, including the raw body, parsed data,
+// timing and the request parameters it answered.
+func (r Response) MarshalJSON() ([]byte, error) {
+	return json.Marshal(responseJSON{
+		Version:    serializationVersion,
+		Raw:        r.Raw,
+		Format:     r.Format,
+		Data:       r.Data,
+		ReceivedAt: r.ReceivedAt,
+		LatencyNS:  int64(r.Latency),
+		Request:    r.Request,
+	})
+}
+
+// UnmarshalJSON restores a response encoded by MarshalJSON. Data is restored
+// as it was stored, not parsed again from Raw.
+func (r *Response) UnmarshalJSON(b []byte) error {
+	var v responseJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v.Version != serializationVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedEncoding, v.Version)
+	}
+	*r = Response{
+		Raw:        v.Raw,
+		Format:     v.Format,
+		Data:       v.Data,
+		ReceivedAt: v.ReceivedAt,
+		Latency:    time.Duration(v.LatencyNS),
+		Request:    v.Request,
+	}
+	return nil
+}
+
+/**
+ * MarshalBinary encodes the response in a compact form: a magic header and
+ * version byte followed by length prefixed strings, the receive time, the
+ * latency and both maps with their keys sorted.
+ */
+func (r Response) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(binaryMagic)
+	buf.WriteByte(serializationVersion)
+
+	writeString(&buf, r.Raw)
+	writeString(&buf, r.Format)
+	writeMap(&buf, r.Data)
+	receivedAt, err := r.ReceivedAt.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	writeString(&buf, string(receivedAt))
+	writeVarint(&buf, int64(r.Latency))
+	writeMap(&buf, r.Request)
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary restores a response encoded by MarshalBinary.
+func (r *Response) UnmarshalBinary(b []byte) error {
+	if !bytes.HasPrefix(b, binaryMagic) || len(b) == len(binaryMagic) {
+		return errors.New("response: not a binary encoded response")
+	}
+	if version := b[len(binaryMagic)]; version != serializationVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedEncoding, version)
+	}
+	d := &decoder{buf: bytes.NewReader(b[len(binaryMagic)+1:])}
+
+	var v Response
+	v.Raw = d.string()
+	v.Format = d.string()
+	v.Data = d.stringMap()
+	receivedAt := d.string()
+	v.Latency = time.Duration(d.varint())
+	v.Request = d.stringMap()
+	if d.err != nil {
+		return fmt.Errorf("response: truncated binary encoding: %v", d.err)
+	}
+	if err := v.ReceivedAt.UnmarshalBinary([]byte(receivedAt)); err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+func writeVarint(buf *bytes.Buffer, n int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutVarint(tmp[:], n)])
+}
+
+func writeUvarint(buf *bytes.Buffer, n uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], n)])
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeUvarint(buf, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+// Write a map as its length plus one, so a nil map (0) survives the round trip.
+func writeMap(buf *bytes.Buffer, m map[string]string) {
+	if m == nil {
+		writeUvarint(buf, 0)
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	writeUvarint(buf, uint64(len(m))+1)
+	for _, key := range keys {
+		writeString(buf, key)
+		writeString(buf, m[key])
+	}
+}
+
+// decoder reads the binary encoding, remembering the first error.
+type decoder struct {
+	buf *bytes.Reader
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	n, err := binary.ReadUvarint(d.buf)
+	d.err = err
+	return n
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	n, err := binary.ReadVarint(d.buf)
+	d.err = err
+	return n
+}
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if n > uint64(d.buf.Len()) {
+		d.err = errors.New("string length out of range")
+		return ""
+	}
+	s := make([]byte, n)
+	_, d.err = io.ReadFull(d.buf, s)
+	return string(s)
+}
+
+func (d *decoder) stringMap() map[string]string {
+	n := d.uvarint()
+	if d.err != nil || n == 0 {
+		return nil
+	}
+	m := make(map[string]string)
+	for i := uint64(1); i < n && d.err == nil; i++ {
+		key := d.string()
+		m[key] = d.string()
+	}
+	return m
+}
@@ -0,0 +1,45 @@
+package response
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSerializationRoundTrip(t *testing.T) {
+	resp := Response{
+		Raw:        "MODE=Q\nAUTO=A\nB2A2=\n",
+		ReceivedAt: time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Latency:    150 * time.Millisecond,
+		Request:    map[string]string{"AAA": "v", "B2A2": ""},
+	}
+	if err := resp.Digest(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := resp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary Response
+	if err := fromBinary.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := resp.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON Response
+	if err := fromJSON.UnmarshalJSON(j); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, got := range map[string]Response{"binary": fromBinary, "JSON": fromJSON} {
+		if got.Raw != resp.Raw || !reflect.DeepEqual(got.Data, resp.Data) ||
+			!reflect.DeepEqual(got.Request, resp.Request) ||
+			!got.ReceivedAt.Equal(resp.ReceivedAt) || got.Latency != resp.Latency {
+			t.Errorf("%s: got %+v, want %+v", name, got, resp)
+		}
+	}
+}
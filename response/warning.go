@@ -0,0 +1,175 @@
+package response
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/**
+ * Warning is a parsed RIS or Kount Central warning or error such as
+ * "399 BAD_OPTN Field: [UDF], Value: [FOO]". Messages that do not start
+ * with a numeric code keep the whole text in Message.
+ */
+type Warning struct {
+	Code    int
+	Name    string // e.g. "BAD_OPTN"
+	Field   string // the offending field, if reported
+	Value   string // the offending value, if reported
+	Message string // the text following the name
+}
+
+// Report whether the warning's code is in the catalog of documented codes.
+func (w Warning) Known() bool {
+	_, ok := codeCatalog[w.Code]
+	return ok
+}
+
+// Get the catalog description of the warning's code.
+func (w Warning) Description() string {
+	return codeCatalog[w.Code]
+}
+
+func (w Warning) String() string {
+	return strings.TrimSpace(strconv.Itoa(w.Code) + " " + w.Name + " " + w.Message)
+}
+
+var (
+	warningPattern = regexp.MustCompile(`^(\d+)\s+([A-Za-z0-9_]+)(?:\s+(.*))?$`)
+	fieldPattern   = regexp.MustCompile(`Field:\s*\[([^\]]*)\]`)
+	valuePattern   = regexp.MustCompile(`Value:\s*\[(.*)\]`)
+)
+
+// ParseWarning parses a single warning or error message.
+func ParseWarning(message string) Warning {
+	message = strings.TrimSpace(message)
+	match := warningPattern.FindStringSubmatch(message)
+	if match == nil {
+		return Warning{Message: message}
+	}
+	code, err := strconv.Atoi(match[1])
+	if err != nil {
+		// a code too large for an int is not a RIS code
+		return Warning{Message: message}
+	}
+
+	w := Warning{Code: code, Name: match[2], Message: match[3]}
+	if m := fieldPattern.FindStringSubmatch(w.Message); m != nil {
+		w.Field = m[1]
+	}
+	if m := valuePattern.FindStringSubmatch(w.Message); m != nil {
+		w.Value = m[1]
+	}
+	return w
+}
+
+func parseWarnings(messages []string) []Warning {
+	warnings := make([]Warning, len(messages))
+	for i, message := range messages {
+		warnings[i] = ParseWarning(message)
+	}
+	return warnings
+}
+
+// Get the warnings associated with this response, parsed.
+func (r *Response) WarningDetails() []Warning {
+	return parseWarnings(r.GetWarnings())
+}
+
+// Get the errors associated with this response, parsed.
+func (r *Response) ErrorDetails() []Warning {
+	return parseWarnings(r.GetErrors())
+}
+
+// Get the Kount Central warnings associated with this response, parsed.
+func (r *Response) KCWarningDetails() []Warning {
+	return parseWarnings(r.GetKCWarnings())
+}
+
+// Get the Kount Central errors associated with this response, parsed.
+func (r *Response) KCErrorDetails() []Warning {
+	return parseWarnings(r.GetKCErrors())
+}
+
+// RIS warning and error codes
+const (
+	CodeMissingVersion     = 201
+	CodeMissingMode        = 202
+	CodeMissingMerchant    = 203
+	CodeMissingSession     = 204
+	CodeMissingTransaction = 205
+	CodeBadOption          = 399
+	CodeUnauthorized       = 501
+	CodeUnauthorizedMerc   = 502
+	CodeUnauthorizedIP     = 503
+	CodeUnauthorizedPass   = 504
+	CodeSystemError        = 601
+)
+
+// documented RIS warning and error codes
+var codeCatalog = map[int]string{
+	201: "version is missing",
+	202: "mode is missing",
+	203: "merchant id is missing",
+	204: "session id is missing",
+	205: "transaction id is missing",
+	211: "currency is missing",
+	212: "total is missing",
+	221: "email is missing",
+	222: "ANI is missing",
+	223: "website is missing",
+	231: "payment type is missing",
+	232: "card number is missing",
+	233: "check MICR is missing",
+	234: "PayPal id is missing",
+	235: "payment token is missing",
+	241: "IP address is missing",
+	251: "merchant acknowledgement is missing",
+	261: "request was not a POST",
+	271: "cart product type is missing",
+	272: "cart product item is missing",
+	273: "cart product description is missing",
+	274: "cart product quantity is missing",
+	275: "cart product price is missing",
+	301: "version is malformed",
+	302: "mode is malformed",
+	303: "merchant id is malformed",
+	304: "session id is malformed",
+	305: "transaction id is malformed",
+	311: "currency is malformed",
+	312: "total is malformed",
+	321: "email is malformed",
+	322: "ANI is malformed",
+	323: "website is malformed",
+	324: "format is malformed",
+	331: "payment type is malformed",
+	332: "card number is malformed",
+	333: "check MICR is malformed",
+	334: "PayPal id is malformed",
+	335: "Google id is malformed",
+	336: "bill-me-later id is malformed",
+	337: "payment encoding is malformed",
+	338: "Green Dot MoneyPak id is malformed",
+	339: "payment token hash is malformed",
+	340: "masked payment token is malformed",
+	341: "IP address is malformed",
+	342: "gift card number is malformed",
+	351: "merchant acknowledgement is malformed",
+	362: "shopping cart is malformed",
+	371: "cart product type is malformed",
+	372: "cart product item is malformed",
+	373: "cart product description is malformed",
+	374: "cart product quantity is malformed",
+	375: "cart product price is malformed",
+	399: "an optional field is malformed",
+	401: "request contains unexpected data",
+	404: "payment token sent with payment type NONE",
+	413: "request is too large",
+	501: "request is not authorized",
+	502: "merchant is not authorized",
+	503: "IP address is not authorized",
+	504: "credentials are not authorized",
+	601: "internal system error",
+	602: "internal encryption error",
+	701: "no response header",
+}
@@ -0,0 +1,112 @@
+package response
+
+import "testing"
+
+func TestParseWarning(t *testing.T) {
+	tests := []struct {
+		message string
+		want    Warning
+	}{
+		{"399 BAD_OPTN Field: [UDF], Value: [FOO]",
+			Warning{Code: 399, Name: "BAD_OPTN", Field: "UDF", Value: "FOO", Message: "Field: [UDF], Value: [FOO]"}},
+		{"  321 BAD_EMAL Field: [EMAL], Value: [john@] ",
+			Warning{Code: 321, Name: "BAD_EMAL", Field: "EMAL", Value: "john@", Message: "Field: [EMAL], Value: [john@]"}},
+		{"399 BAD_OPTN Field: [UDF[COLOR]], Value: [a [b] c]",
+			Warning{Code: 399, Name: "BAD_OPTN", Field: "UDF[COLOR", Value: "a [b] c", Message: "Field: [UDF[COLOR]], Value: [a [b] c]"}},
+		{"201 MISSING_VERS",
+			Warning{Code: 201, Name: "MISSING_VERS"}},
+		{"601 SYS_ERR Internal error",
+			Warning{Code: 601, Name: "SYS_ERR", Message: "Internal error"}},
+		{"888 NEW_CODE Field: [ZZZZ]",
+			Warning{Code: 888, Name: "NEW_CODE", Field: "ZZZZ", Message: "Field: [ZZZZ]"}},
+	}
+	for _, test := range tests {
+		if got := ParseWarning(test.message); got != test.want {
+			t.Errorf("ParseWarning(%q) = %+v, want %+v", test.message, got, test.want)
+		}
+	}
+}
+
+func TestParseMalformedWarning(t *testing.T) {
+	for _, message := range []string{
+		"",
+		"BAD_OPTN Field: [UDF]",
+		"399",
+		"399 ",
+		"-399 BAD_OPTN",
+		"x399 BAD_OPTN",
+		"399 BAD-OPTN Field: [UDF]",
+		"99999999999999999999999 BAD_OPTN",
+	} {
+		got := ParseWarning(message)
+		want := Warning{Message: message}
+		if message == "399 " {
+			want.Message = "399"
+		}
+		if got != want {
+			t.Errorf("ParseWarning(%q) = %+v, want %+v", message, got, want)
+		}
+		if got.Known() {
+			t.Errorf("ParseWarning(%q) reported a known code", message)
+		}
+	}
+}
+
+func TestWarningCatalog(t *testing.T) {
+	tests := []struct {
+		code        int
+		known       bool
+		description string
+	}{
+		{CodeMissingVersion, true, "version is missing"},
+		{CodeBadOption, true, "an optional field is malformed"},
+		{CodeUnauthorizedPass, true, "credentials are not authorized"},
+		{CodeSystemError, true, "internal system error"},
+		{888, false, ""},
+		{0, false, ""},
+	}
+	for _, test := range tests {
+		w := Warning{Code: test.code}
+		if w.Known() != test.known || w.Description() != test.description {
+			t.Errorf("code %d: Known() = %v, Description() = %q", test.code, w.Known(), w.Description())
+		}
+	}
+
+	// every named code is documented
+	for _, code := range []int{
+		CodeMissingVersion, CodeMissingMode, CodeMissingMerchant, CodeMissingSession,
+		CodeMissingTransaction, CodeBadOption, CodeUnauthorized, CodeUnauthorizedMerc,
+		CodeUnauthorizedIP, CodeUnauthorizedPass, CodeSystemError,
+	} {
+		if _, ok := codeCatalog[code]; !ok {
+			t.Errorf("code %d is not in the catalog", code)
+		}
+	}
+}
+
+func TestWarningDetails(t *testing.T) {
+	resp := &Response{Data: map[string]string{
+		"WARNING_COUNT":    "2",
+		"WARNING_0":        "399 BAD_OPTN Field: [UDF], Value: [x]",
+		"WARNING_1":        "not a coded warning",
+		"ERROR_COUNT":      "1",
+		"ERROR_0":          "321 BAD_EMAL Field: [EMAL], Value: [john@]",
+		"KC_WARNING_COUNT": "1",
+		"KC_WARNING_1":     "399 BAD_OPTN Field: [KC], Value: [y]",
+		"KC_ERROR_COUNT":   "0",
+	}}
+
+	warnings := resp.WarningDetails()
+	if len(warnings) != 2 || warnings[0].Field != "UDF" || warnings[1].Message != "not a coded warning" {
+		t.Errorf("warnings %+v", warnings)
+	}
+	if errs := resp.ErrorDetails(); len(errs) != 1 || errs[0].Code != 321 || errs[0].String() != "321 BAD_EMAL Field: [EMAL], Value: [john@]" {
+		t.Errorf("errors %+v", errs)
+	}
+	if kc := resp.KCWarningDetails(); len(kc) != 1 || kc[0].Value != "y" {
+		t.Errorf("Kount Central warnings %+v", kc)
+	}
+	if kc := resp.KCErrorDetails(); len(kc) != 0 {
+		t.Errorf("Kount Central errors %+v", kc)
+	}
+}
@@ -0,0 +1,182 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// how long an API key is used before it should be rotated, when the key
+// itself carries no expiry
+const DefaultKeyRotationPeriod = 365 * 24 * time.Hour
+
+var (
+	ErrAPIKeyNotJWT           = errors.New("settings: API key is not a JWT")
+	ErrAPIKeyMerchantMismatch = errors.New("settings: API key was issued for a different merchant id")
+	ErrAPIKeyExpired          = errors.New("settings: API key has expired")
+)
+
+// APIKeyClaims are the claims of a Kount API key. The key's subject is the
+// merchant id it was issued for.
+type APIKeyClaims struct {
+	Issuer    string
+	Subject   string
+	Audience  string
+	IssuedAt  time.Time
+	ExpiresAt time.Time // zero when the key does not expire
+}
+
+// the JWT payload of an API key
+type apiKeyPayload struct {
+	Issuer   string          `json:"iss"`
+	Subject  string          `json:"sub"`
+	Audience json.RawMessage `json:"aud"`
+	IssuedAt json.Number     `json:"iat"`
+	Expires  json.Number     `json:"exp"`
+}
+
+/**
+ * DecodeAPIKey reads the claims of an API key without verifying its
+ * signature and without network access. Errors never include the key.
+ */
+func DecodeAPIKey(key string) (APIKeyClaims, error) {
+	var claims APIKeyClaims
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	if len(parts) != 3 {
+		return claims, ErrAPIKeyNotJWT
+	}
+	body, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, ErrAPIKeyNotJWT
+	}
+
+	var payload apiKeyPayload
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return claims, ErrAPIKeyNotJWT
+	}
+
+	claims.Issuer = payload.Issuer
+	claims.Subject = payload.Subject
+	claims.Audience = audience(payload.Audience)
+	if claims.IssuedAt, err = unixTime(payload.IssuedAt); err != nil {
+		return claims, ErrAPIKeyNotJWT
+	}
+	if claims.ExpiresAt, err = unixTime(payload.Expires); err != nil {
+		return claims, ErrAPIKeyNotJWT
+	}
+	return claims, nil
+}
+
+// Read an "aud" claim, which may be a string or a list of strings.
+func audience(raw json.RawMessage) string {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return strings.Join(list, ",")
+	}
+	return ""
+}
+
+func unixTime(n json.Number) (time.Time, error) {
+	if n == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(seconds), 0).UTC(), nil
+}
+
+/**
+ * RotationDue returns when the key should be rotated: its expiry if it has
+ * one, otherwise period after it was issued. Zero if neither is known.
+ */
+func (c APIKeyClaims) RotationDue(period time.Duration) time.Time {
+	if !c.ExpiresAt.IsZero() {
+		return c.ExpiresAt
+	}
+	if c.IssuedAt.IsZero() {
+		return time.Time{}
+	}
+	return c.IssuedAt.Add(period)
+}
+
+// RotationWarning reports an API key that is due, or overdue, for rotation.
+type RotationWarning struct {
+	Due     time.Time
+	Overdue bool
+}
+
+func (w *RotationWarning) Error() string {
+	if w.Overdue {
+		return "settings: API key has been due for rotation since " + w.Due.Format("2006-01-02")
+	}
+	return "settings: API key is due for rotation on " + w.Due.Format("2006-01-02")
+}
+
+// Get the claims of the API key.
+func (s *Settings) APIKeyClaims() (APIKeyClaims, error) {
+	credentials, err := s.GetCredentials()
+	if err != nil {
+		return APIKeyClaims{}, err
+	}
+	return DecodeAPIKey(credentials.APIKey)
+}
+
+// Set how long an API key without an expiry is used before rotation.
+func (s *Settings) SetKeyRotationPeriod(period time.Duration) {
+	s.keyRotationPeriod = period
+}
+
+// Get how long an API key without an expiry is used before rotation.
+func (s *Settings) GetKeyRotationPeriod() time.Duration {
+	if s.keyRotationPeriod > 0 {
+		return s.keyRotationPeriod
+	}
+	return DefaultKeyRotationPeriod
+}
+
+// Verify the API key was issued for the settings' merchant id.
+func (s *Settings) CheckAPIKey() error {
+	claims, err := s.APIKeyClaims()
+	if err != nil {
+		return err
+	}
+	if claims.Subject != s.merchantID {
+		return ErrAPIKeyMerchantMismatch
+	}
+	return nil
+}
+
+/**
+ * CheckKeyRotation returns ErrAPIKeyExpired for a key past its expiry and a
+ * *RotationWarning for a key whose rotation date has passed or is within
+ * warnBefore of now. Keys without an issue or expiry time are never reported.
+ */
+func (s *Settings) CheckKeyRotation(now time.Time, warnBefore time.Duration) error {
+	claims, err := s.APIKeyClaims()
+	if err != nil {
+		return err
+	}
+	due := claims.RotationDue(s.GetKeyRotationPeriod())
+	switch {
+	case due.IsZero():
+		return nil
+	case !now.Before(due) && !claims.ExpiresAt.IsZero():
+		return ErrAPIKeyExpired
+	case !now.Before(due):
+		return &RotationWarning{Due: due, Overdue: true}
+	case now.Add(warnBefore).After(due):
+		return &RotationWarning{Due: due}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package settings
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+var keyTime = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// Build an API key for merchant 123456 with the given issue and expiry times;
+// zero times are left out.
+func timedAPIKey(issued, expires time.Time) string {
+	payload := `{"iss":"https://kount.example","sub":"123456","aud":["RIS","KC"]`
+	if !issued.IsZero() {
+		payload += `,"iat":` + strconv.FormatInt(issued.Unix(), 10)
+	}
+	if !expires.IsZero() {
+		payload += `,"exp":` + strconv.FormatInt(expires.Unix(), 10)
+	}
+	return testAPIKey(payload + "}")
+}
+
+func TestDecodeAPIKey(t *testing.T) {
+	claims, err := DecodeAPIKey(" " + timedAPIKey(keyTime, keyTime.AddDate(1, 0, 0)) + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := APIKeyClaims{
+		Issuer:    "https://kount.example",
+		Subject:   "123456",
+		Audience:  "RIS,KC",
+		IssuedAt:  keyTime,
+		ExpiresAt: keyTime.AddDate(1, 0, 0),
+	}
+	if claims != want {
+		t.Errorf("got %+v, want %+v", claims, want)
+	}
+}
+
+func TestDecodeMalformedAPIKey(t *testing.T) {
+	keys := []string{
+		"",
+		"not-a-jwt",
+		"a.b",
+		"a.b.c.d",
+		"header.!!!.sig",
+		testAPIKey(`not json`),
+		testAPIKey(`{"sub":"123456","iat":"yesterday"}`),
+		testAPIKey(`{"sub":"123456","exp":"soon"}`),
+	}
+	for _, key := range keys {
+		if _, err := DecodeAPIKey(key); err != ErrAPIKeyNotJWT {
+			t.Errorf("%q: got %v, want ErrAPIKeyNotJWT", key, err)
+		}
+	}
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	tests := []struct {
+		apiKey string
+		want   error
+	}{
+		{testAPIKey(`{"sub":"123456"}`), nil},
+		{testAPIKey(`{"sub":"654321"}`), ErrAPIKeyMerchantMismatch},
+		{testAPIKey(`{}`), ErrAPIKeyMerchantMismatch},
+		{"opaque-key", ErrAPIKeyNotJWT},
+	}
+	for _, test := range tests {
+		s := New("123456", TestRISURL, test.apiKey, validConfigKey)
+		if err := s.CheckAPIKey(); err != test.want {
+			t.Errorf("%q: got %v, want %v", test.apiKey, err, test.want)
+		}
+	}
+}
+
+func TestCheckKeyRotation(t *testing.T) {
+	const warnBefore = 30 * 24 * time.Hour
+	expires := keyTime.AddDate(1, 0, 0)
+	tests := []struct {
+		name            string
+		issued, expires time.Time
+		now             time.Time
+		want            error
+	}{
+		{"fresh", keyTime, expires, keyTime.AddDate(0, 1, 0), nil},
+		{"near expiry", keyTime, expires, expires.AddDate(0, 0, -10), &RotationWarning{Due: expires}},
+		{"expired", keyTime, expires, expires, ErrAPIKeyExpired},
+		{"long expired", keyTime, expires, expires.AddDate(1, 0, 0), ErrAPIKeyExpired},
+		{"no expiry, fresh", keyTime, time.Time{}, keyTime.AddDate(0, 6, 0), nil},
+		{"no expiry, near rotation", keyTime, time.Time{}, keyTime.Add(DefaultKeyRotationPeriod - 24*time.Hour), &RotationWarning{Due: keyTime.Add(DefaultKeyRotationPeriod)}},
+		{"no expiry, overdue", keyTime, time.Time{}, keyTime.Add(DefaultKeyRotationPeriod), &RotationWarning{Due: keyTime.Add(DefaultKeyRotationPeriod), Overdue: true}},
+		{"no times", time.Time{}, time.Time{}, keyTime, nil},
+	}
+	for _, test := range tests {
+		s := New("123456", TestRISURL, timedAPIKey(test.issued, test.expires), validConfigKey)
+		err := s.CheckKeyRotation(test.now, warnBefore)
+		if want, ok := test.want.(*RotationWarning); ok {
+			got, ok := err.(*RotationWarning)
+			if !ok || *got != *want {
+				t.Errorf("%s: got %v, want %v", test.name, err, want)
+			}
+			continue
+		}
+		if err != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestKeyRotationPeriod(t *testing.T) {
+	s := New("123456", TestRISURL, timedAPIKey(keyTime, time.Time{}), validConfigKey)
+	if s.GetKeyRotationPeriod() != DefaultKeyRotationPeriod {
+		t.Errorf("default period: got %v", s.GetKeyRotationPeriod())
+	}
+	s.SetKeyRotationPeriod(90 * 24 * time.Hour)
+	due := keyTime.AddDate(0, 0, 90)
+	err := s.CheckKeyRotation(due.AddDate(0, 0, -1), 7*24*time.Hour)
+	if warning, ok := err.(*RotationWarning); !ok || !warning.Due.Equal(due) || warning.Overdue {
+		t.Errorf("got %v, want a warning due %s", err, due)
+	}
+}
+
+func TestRotationWarningMessages(t *testing.T) {
+	due := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := (&RotationWarning{Due: due}).Error(), "settings: API key is due for rotation on 2027-03-01"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := (&RotationWarning{Due: due, Overdue: true}).Error(), "settings: API key has been due for rotation since 2027-03-01"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCheckKeyRotationMalformedKey(t *testing.T) {
+	s := New("123456", TestRISURL, "opaque-key", validConfigKey)
+	if err := s.CheckKeyRotation(keyTime, time.Hour); err != ErrAPIKeyNotJWT {
+		t.Errorf("got %v, want ErrAPIKeyNotJWT", err)
+	}
+}
@@ -0,0 +1,254 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNoCredentials = errors.New("settings: credential provider returned no API key")
+
+// Credentials are the secrets a request is sent with.
+type Credentials struct {
+	APIKey    string
+	ConfigKey string
+}
+
+// CredentialProvider supplies credentials; it is consulted on every send.
+type CredentialProvider interface {
+	Credentials() (Credentials, error)
+}
+
+/**
+ * RotatingProvider is a CredentialProvider that remembers the credentials it
+ * returned before the current ones, so a request rejected with the new API
+ * key during a rotation can be retried once with the previous key.
+ */
+type RotatingProvider interface {
+	CredentialProvider
+	PreviousCredentials() (Credentials, bool)
+}
+
+// Set the provider consulted for the API key and config key on each send.
+func (s *Settings) SetCredentialProvider(provider CredentialProvider) {
+	s.credentials = provider
+}
+
+// Get the current credentials from the provider, or the keys given to New.
+// A provider without a config key falls back to the one given to New.
+func (s *Settings) GetCredentials() (Credentials, error) {
+	if s.credentials == nil {
+		return Credentials{APIKey: s.apiKey, ConfigKey: s.configKey}, nil
+	}
+	c, err := s.credentials.Credentials()
+	if err != nil {
+		return c, err
+	}
+	if c.APIKey == "" {
+		return c, ErrNoCredentials
+	}
+	if c.ConfigKey == "" {
+		c.ConfigKey = s.configKey
+	}
+	return c, nil
+}
+
+// Get the credentials used before the current ones, if the provider rotates.
+func (s *Settings) GetPreviousCredentials() (Credentials, bool) {
+	if rotating, ok := s.credentials.(RotatingProvider); ok {
+		return rotating.PreviousCredentials()
+	}
+	return Credentials{}, false
+}
+
+// track the previous credentials of a provider
+type rotation struct {
+	mu       sync.Mutex
+	current  Credentials
+	previous Credentials
+	rotated  bool
+}
+
+// Record the latest credentials, keeping the old ones if they changed.
+func (r *rotation) update(c Credentials) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current != (Credentials{}) && r.current != c {
+		r.previous = r.current
+		r.rotated = true
+	}
+	r.current = c
+}
+
+func (r *rotation) PreviousCredentials() (Credentials, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.previous, r.rotated
+}
+
+// StaticProvider always returns the same credentials.
+type StaticProvider Credentials
+
+func (p StaticProvider) Credentials() (Credentials, error) {
+	return Credentials(p), nil
+}
+
+// EnvProvider reads the credentials from environment variables on each call.
+type EnvProvider struct {
+	rotation
+	apiKeyVar    string
+	configKeyVar string
+}
+
+// NewEnvProvider reads the named variables; empty names use KOUNT_API_KEY and
+// KOUNT_CONFIG_KEY.
+func NewEnvProvider(apiKeyVar, configKeyVar string) *EnvProvider {
+	if apiKeyVar == "" {
+		apiKeyVar = EnvAPIKey
+	}
+	if configKeyVar == "" {
+		configKeyVar = EnvConfigKey
+	}
+	return &EnvProvider{apiKeyVar: apiKeyVar, configKeyVar: configKeyVar}
+}
+
+func (p *EnvProvider) Credentials() (Credentials, error) {
+	c := Credentials{
+		APIKey:    os.Getenv(p.apiKeyVar),
+		ConfigKey: os.Getenv(p.configKeyVar),
+	}
+	if c.APIKey == "" {
+		return c, fmt.Errorf("settings: %s is not set", p.apiKeyVar)
+	}
+	p.update(c)
+	return c, nil
+}
+
+/**
+ * FileProvider reads the credentials from a JSON or YAML file with api_key
+ * and config_key entries and reloads it when its modification time or size
+ * changes, checking at most once per interval. If a reload fails the last
+ * good credentials are kept.
+ */
+type FileProvider struct {
+	rotation
+	path     string
+	interval time.Duration
+
+	fileMu    sync.Mutex
+	checked   time.Time
+	modTime   time.Time
+	size      int64
+	loaded    Credentials
+	loadedErr error
+}
+
+// NewFileProvider loads the credentials file, failing if it cannot be read.
+func NewFileProvider(path string, interval time.Duration) (*FileProvider, error) {
+	p := &FileProvider{path: path, interval: interval}
+	if _, err := p.Credentials(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *FileProvider) Credentials() (Credentials, error) {
+	p.fileMu.Lock()
+	defer p.fileMu.Unlock()
+
+	now := time.Now()
+	if !p.checked.IsZero() && now.Sub(p.checked) < p.interval {
+		return p.loaded, p.loadedErr
+	}
+	p.checked = now
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return p.fallback(err)
+	}
+	if p.loadedErr == nil && p.loaded.APIKey != "" &&
+		info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.loaded, nil
+	}
+
+	c, err := readCredentialsFile(p.path)
+	if err != nil {
+		return p.fallback(err)
+	}
+	p.modTime, p.size = info.ModTime(), info.Size()
+	p.loaded, p.loadedErr = c, nil
+	p.update(c)
+	return c, nil
+}
+
+// Keep serving the last good credentials when the file cannot be read.
+func (p *FileProvider) fallback(err error) (Credentials, error) {
+	if p.loaded.APIKey != "" {
+		return p.loaded, nil
+	}
+	p.loadedErr = err
+	return Credentials{}, err
+}
+
+func readCredentialsFile(path string) (Credentials, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var cfg fileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		cfg, err = parseYAML(string(body))
+	default:
+		err = json.Unmarshal(body, &cfg)
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("settings: %s: %v", path, err)
+	}
+	if cfg.APIKey == "" {
+		return Credentials{}, fmt.Errorf("settings: %s: api_key is missing", path)
+	}
+	return Credentials{APIKey: cfg.APIKey, ConfigKey: cfg.ConfigKey}, nil
+}
+
+// CachingProvider caches another provider's credentials for a TTL.
+type CachingProvider struct {
+	provider CredentialProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	cached  Credentials
+	expires time.Time
+}
+
+func NewCachingProvider(provider CredentialProvider, ttl time.Duration) *CachingProvider {
+	return &CachingProvider{provider: provider, ttl: ttl}
+}
+
+func (p *CachingProvider) Credentials() (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Now().Before(p.expires) {
+		return p.cached, nil
+	}
+	c, err := p.provider.Credentials()
+	if err != nil {
+		return c, err
+	}
+	p.cached, p.expires = c, time.Now().Add(p.ttl)
+	return c, nil
+}
+
+// Get the wrapped provider's previous credentials, if it rotates.
+func (p *CachingProvider) PreviousCredentials() (Credentials, bool) {
+	if rotating, ok := p.provider.(RotatingProvider); ok {
+		return rotating.PreviousCredentials()
+	}
+	return Credentials{}, false
+}
@@ -0,0 +1,31 @@
+package settings
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGettersDoNotFallBackOnProviderError(t *testing.T) {
+	os.Unsetenv("KOUNTTEST_UNSET_API_KEY")
+	s := New("123456", "https://risk.test.kount.net", "static", "static-config-key")
+	s.SetCredentialProvider(NewEnvProvider("KOUNTTEST_UNSET_API_KEY", ""))
+
+	if _, err := s.GetCredentials(); err == nil {
+		t.Fatal("GetCredentials: want an error")
+	}
+	if key := s.GetAPIKey(); key != "" {
+		t.Errorf("GetAPIKey: got %q", key)
+	}
+	if key := s.GetConfigKey(); key != "" {
+		t.Errorf("GetConfigKey: got %q", key)
+	}
+}
+
+func TestProviderConfigKeyDefaultsToStatic(t *testing.T) {
+	s := New("123456", "https://risk.test.kount.net", "static", "static-config-key")
+	s.SetCredentialProvider(StaticProvider{APIKey: "rotated"})
+
+	if s.GetAPIKey() != "rotated" || s.GetConfigKey() != "static-config-key" {
+		t.Errorf("got %q, %q", s.GetAPIKey(), s.GetConfigKey())
+	}
+}
@@ -0,0 +1,123 @@
+package settings
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+type Environment int
+
+const (
+	EnvironmentUnspecified Environment = iota
+	EnvironmentTest
+	EnvironmentProduction
+)
+
+// Kount's endpoints for each environment
+const (
+	TestRISURL                 = "https://risk.test.kount.net"
+	ProductionRISURL           = "https://risk.kount.net"
+	TestDataCollectorURL       = "https://tst.kaxsdc.com"
+	ProductionDataCollectorURL = "https://ssl.kaptcha.com"
+)
+
+var (
+	ErrEnvironmentMismatch = errors.New("settings: endpoint does not belong to the configured environment")
+	ErrProductionForbidden = errors.New("settings: production endpoints are forbidden in this process")
+)
+
+var productionHosts = map[string]bool{
+	"risk.kount.net":  true,
+	"ssl.kaptcha.com": true,
+}
+
+// set when production endpoints must not be used, see ForbidProduction
+var productionForbidden int32
+
+func init() {
+	if os.Getenv("KOUNT_FORBID_PRODUCTION") != "" {
+		ForbidProduction()
+	}
+}
+
+func (e Environment) String() string {
+	switch e {
+	case EnvironmentTest:
+		return "test"
+	case EnvironmentProduction:
+		return "production"
+	}
+	return "unspecified"
+}
+
+/**
+ * ForbidProduction makes every later CheckEndpoint call fail for production
+ * endpoints. The guard is opt-in: besides calling ForbidProduction, it is
+ * switched on by the KOUNT_FORBID_PRODUCTION environment variable and the
+ * kount_noprod build tag. It cannot be switched off again.
+ */
+func ForbidProduction() {
+	atomic.StoreInt32(&productionForbidden, 1)
+}
+
+// Report whether production endpoints are forbidden in this process.
+func ProductionForbidden() bool {
+	return atomic.LoadInt32(&productionForbidden) == 1
+}
+
+// NewForEnvironment creates settings using the environment's RIS and data
+// collector endpoints.
+func NewForEnvironment(env Environment, merchantID, apiKey, configKey string) *Settings {
+	s := New(merchantID, TestRISURL, apiKey, configKey)
+	s.dataCollectorURL = TestDataCollectorURL
+	if env == EnvironmentProduction {
+		s.risURL = ProductionRISURL
+		s.dataCollectorURL = ProductionDataCollectorURL
+	}
+	s.environment = env
+	return s
+}
+
+// Set the environment the settings are meant for.
+func (s *Settings) SetEnvironment(env Environment) {
+	s.environment = env
+}
+
+// Get the environment the settings are meant for.
+func (s *Settings) GetEnvironment() Environment {
+	return s.environment
+}
+
+/**
+ * CheckEndpoint verifies the RIS and data collector URLs against the
+ * environment: a test environment must not point at a production endpoint and
+ * a production environment must point only at production endpoints. Without
+ * an environment only the production guard applies.
+ */
+func (s *Settings) CheckEndpoint() error {
+	for _, rawURL := range []string{s.risURL, s.dataCollectorURL} {
+		if rawURL == "" {
+			continue
+		}
+		production := isProductionURL(rawURL)
+		switch {
+		case production && ProductionForbidden():
+			return ErrProductionForbidden
+		case production && s.environment == EnvironmentTest,
+			!production && s.environment == EnvironmentProduction:
+			return ErrEnvironmentMismatch
+		}
+	}
+	return nil
+}
+
+func isProductionURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return productionHosts[strings.ToLower(u.Hostname())]
+}
@@ -0,0 +1,89 @@
+package settings
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// Set the production guard for one test, restoring it afterwards.
+func setProductionForbidden(t *testing.T, forbidden bool) {
+	t.Helper()
+	previous := atomic.LoadInt32(&productionForbidden)
+	value := int32(0)
+	if forbidden {
+		value = 1
+	}
+	atomic.StoreInt32(&productionForbidden, value)
+	t.Cleanup(func() { atomic.StoreInt32(&productionForbidden, previous) })
+}
+
+func TestNewForEnvironment(t *testing.T) {
+	tests := []struct {
+		env                  Environment
+		risURL, collectorURL string
+	}{
+		{EnvironmentTest, TestRISURL, TestDataCollectorURL},
+		{EnvironmentProduction, ProductionRISURL, ProductionDataCollectorURL},
+		{EnvironmentUnspecified, TestRISURL, TestDataCollectorURL},
+	}
+	for _, test := range tests {
+		s := NewForEnvironment(test.env, "123456", "key", "config")
+		if s.GetRISURL() != test.risURL || s.GetDataCollectorURL() != test.collectorURL {
+			t.Errorf("%s: got %s and %s", test.env, s.GetRISURL(), s.GetDataCollectorURL())
+		}
+		if s.GetEnvironment() != test.env || s.GetMerchantID() != "123456" {
+			t.Errorf("%s: got environment %s, merchant %s", test.env, s.GetEnvironment(), s.GetMerchantID())
+		}
+	}
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	setProductionForbidden(t, false)
+	tests := []struct {
+		env                  Environment
+		risURL, collectorURL string
+		want                 error
+	}{
+		{EnvironmentTest, TestRISURL, TestDataCollectorURL, nil},
+		{EnvironmentTest, TestRISURL, "", nil},
+		{EnvironmentTest, ProductionRISURL, TestDataCollectorURL, ErrEnvironmentMismatch},
+		{EnvironmentTest, TestRISURL, ProductionDataCollectorURL, ErrEnvironmentMismatch},
+		{EnvironmentTest, "https://RISK.KOUNT.NET:443/", "", ErrEnvironmentMismatch},
+		{EnvironmentProduction, ProductionRISURL, ProductionDataCollectorURL, nil},
+		{EnvironmentProduction, TestRISURL, ProductionDataCollectorURL, ErrEnvironmentMismatch},
+		{EnvironmentProduction, "http://localhost:8080", "", ErrEnvironmentMismatch},
+		{EnvironmentUnspecified, ProductionRISURL, TestDataCollectorURL, nil},
+		{EnvironmentUnspecified, "http://localhost:8080", "", nil},
+	}
+	for _, test := range tests {
+		s := New("123456", test.risURL, "key", "config")
+		s.SetDataCollectorURL(test.collectorURL)
+		s.SetEnvironment(test.env)
+		if got := s.CheckEndpoint(); got != test.want {
+			t.Errorf("%s %s %s: got %v, want %v", test.env, test.risURL, test.collectorURL, got, test.want)
+		}
+	}
+}
+
+func TestForbidProduction(t *testing.T) {
+	setProductionForbidden(t, false)
+	production := NewForEnvironment(EnvironmentProduction, "123456", "key", "config")
+	unspecified := New("123456", ProductionRISURL, "key", "config")
+	testEnv := NewForEnvironment(EnvironmentTest, "123456", "key", "config")
+	if ProductionForbidden() || production.CheckEndpoint() != nil || unspecified.CheckEndpoint() != nil {
+		t.Fatal("production is forbidden without opting in")
+	}
+
+	ForbidProduction()
+	if !ProductionForbidden() {
+		t.Fatal("ForbidProduction did not forbid production")
+	}
+	for _, s := range []*Settings{production, unspecified} {
+		if err := s.CheckEndpoint(); err != ErrProductionForbidden {
+			t.Errorf("%s: got %v, want ErrProductionForbidden", s.GetRISURL(), err)
+		}
+	}
+	if err := testEnv.CheckEndpoint(); err != nil {
+		t.Errorf("test endpoints: got %v", err)
+	}
+}
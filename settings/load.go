@@ -0,0 +1,214 @@
+package settings
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// environment variables read by FromEnv
+const (
+	EnvMerchantID       = "KOUNT_MERCHANT_ID"
+	EnvRISURL           = "KOUNT_RIS_URL"
+	EnvAPIKey           = "KOUNT_API_KEY"
+	EnvConfigKey        = "KOUNT_CONFIG_KEY"
+	EnvEnvironment      = "KOUNT_ENVIRONMENT"
+	EnvDataCollectorURL = "KOUNT_DATA_COLLECTOR_URL"
+	EnvVersion          = "KOUNT_VERSION"
+)
+
+// the settings file format
+type fileConfig struct {
+	MerchantID       string            `json:"merchant_id"`
+	RISURL           string            `json:"ris_url"`
+	APIKey           string            `json:"api_key"`
+	ConfigKey        string            `json:"config_key"`
+	Environment      string            `json:"environment"`
+	DataCollectorURL string            `json:"data_collector_url"`
+	Version          string            `json:"version"`
+	WebsiteVersions  map[string]string `json:"website_versions"`
+}
+
+// ParseEnvironment parses "test" or "production"; empty is unspecified.
+func ParseEnvironment(name string) (Environment, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return EnvironmentUnspecified, nil
+	case "test", "sandbox":
+		return EnvironmentTest, nil
+	case "production", "prod":
+		return EnvironmentProduction, nil
+	}
+	return EnvironmentUnspecified, &ValidationError{Field: "environment", Reason: `must be "test" or "production"`}
+}
+
+/**
+ * FromEnv loads settings from the KOUNT_* environment variables and validates
+ * them. When KOUNT_RIS_URL is not set the environment's endpoint is used.
+ */
+func FromEnv() (*Settings, error) {
+	return fileConfig{
+		MerchantID:       os.Getenv(EnvMerchantID),
+		RISURL:           os.Getenv(EnvRISURL),
+		APIKey:           os.Getenv(EnvAPIKey),
+		ConfigKey:        os.Getenv(EnvConfigKey),
+		Environment:      os.Getenv(EnvEnvironment),
+		DataCollectorURL: os.Getenv(EnvDataCollectorURL),
+		Version:          os.Getenv(EnvVersion),
+	}.settings()
+}
+
+/**
+ * FromFile loads settings from a JSON (.json) or YAML (.yaml, .yml) file and
+ * validates them. Keys are merchant_id, ris_url, api_key, config_key,
+ * environment, data_collector_url, version and website_versions, a map of
+ * SITE to RIS version. Other keys are rejected, so a misspelt key is not
+ * silently ignored.
+ */
+func FromFile(path string) (*Settings, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg fileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = decodeConfig(body, &cfg)
+	case ".yaml", ".yml":
+		cfg, err = parseYAML(string(body))
+	default:
+		return nil, fmt.Errorf("settings: %s: unsupported file type", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("settings: %s: %v", path, err)
+	}
+	return cfg.settings()
+}
+
+func (c fileConfig) settings() (*Settings, error) {
+	env, err := ParseEnvironment(c.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewForEnvironment(env, c.MerchantID, c.APIKey, c.ConfigKey)
+	if env == EnvironmentUnspecified {
+		s.risURL, s.dataCollectorURL = "", ""
+	}
+	if c.RISURL != "" {
+		s.risURL = c.RISURL
+	}
+	if c.DataCollectorURL != "" {
+		s.dataCollectorURL = c.DataCollectorURL
+	}
+	s.version = c.Version
+	for site, version := range c.WebsiteVersions {
+		s.SetWebsiteVersion(site, version)
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+/**
+ * Parse the YAML subset settings files need: "key: value" pairs, values
+ * optionally quoted, comments, and one level of nested "key: value" pairs
+ * for website_versions.
+ */
+func parseYAML(body string) (fileConfig, error) {
+	var cfg fileConfig
+	top := make(map[string]interface{})
+	var nested map[string]string
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for n := 1; scanner.Scan(); n++ {
+		line := stripYAMLComment(scanner.Text())
+		if strings.TrimSpace(line) == "" || strings.TrimSpace(line) == "---" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			return cfg, fmt.Errorf("line %d: expected \"key: value\"", n)
+		}
+		key := strings.TrimSpace(line[:colon])
+		value, err := unquoteYAML(strings.TrimSpace(line[colon+1:]))
+		if err != nil {
+			return cfg, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		switch {
+		case indented && nested != nil:
+			nested[key] = value
+		case indented:
+			return cfg, fmt.Errorf("line %d: unexpected indentation", n)
+		case value == "":
+			nested = make(map[string]string)
+			top[key] = nested
+		default:
+			nested = nil
+			top[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cfg, err
+	}
+
+	// reuse the JSON field names and types
+	encoded, err := json.Marshal(top)
+	if err != nil {
+		return cfg, err
+	}
+	err = decodeConfig(encoded, &cfg)
+	return cfg, err
+}
+
+// Decode a JSON settings file, rejecting unknown keys.
+func decodeConfig(body []byte, cfg *fileConfig) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(cfg)
+}
+
+// Remove a trailing comment that is not inside quotes.
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return strings.TrimRight(line, " \t\r")
+}
+
+func unquoteYAML(value string) (string, error) {
+	if len(value) < 2 {
+		return value, nil
+	}
+	switch {
+	case value[0] == '"' && value[len(value)-1] == '"':
+		var s string
+		if err := json.Unmarshal([]byte(value), &s); err != nil {
+			return "", fmt.Errorf("invalid quoted value")
+		}
+		return s, nil
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.Replace(value[1:len(value)-1], "''", "'", -1), nil
+	}
+	return value, nil
+}
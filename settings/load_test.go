@@ -0,0 +1,201 @@
+package settings
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name, body string
+		want       fileConfig
+	}{
+		{
+			name: "plain values",
+			body: "merchant_id: 123456\nris_url: https://risk.test.kount.net\n",
+			want: fileConfig{MerchantID: "123456", RISURL: "https://risk.test.kount.net"},
+		},
+		{
+			name: "double quotes",
+			body: `api_key: "a \"quoted\" key # not a comment"`,
+			want: fileConfig{APIKey: `a "quoted" key # not a comment`},
+		},
+		{
+			name: "single quotes",
+			body: `config_key: 'it''s # kept'`,
+			want: fileConfig{ConfigKey: "it's # kept"},
+		},
+		{
+			name: "comments",
+			body: "# settings\n---\nmerchant_id: 123456 # trailing\nconfig_key: abc#def\n\n",
+			want: fileConfig{MerchantID: "123456", ConfigKey: "abc#def"},
+		},
+		{
+			name: "nested website versions",
+			body: "website_versions:\n  SHOP: \"0720\"\n\tOTHER: 0710\nversion: 0700\n",
+			want: fileConfig{Version: "0700", WebsiteVersions: map[string]string{"SHOP": "0720", "OTHER": "0710"}},
+		},
+		{
+			name: "windows line endings",
+			body: "merchant_id: 123456\r\nenvironment: test\r\n",
+			want: fileConfig{MerchantID: "123456", Environment: "test"},
+		},
+	}
+	for _, test := range tests {
+		got, err := parseYAML(test.body)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"no colon", "merchant_id 123456", `line 1: expected "key: value"`},
+		{"indented top level", "merchant_id: 123456\n  ris_url: https://risk.test.kount.net", "line 2: unexpected indentation"},
+		{"indented first line", "  merchant_id: 123456", "line 1: unexpected indentation"},
+		{"bad quoting", `api_key: "bad \q escape"`, "line 1: invalid quoted value"},
+		{"unknown key", "merchant_id: 123456\nmerchantid: 123456", `unknown field "merchantid"`},
+		{"nested unknown key", "website_version:\n  SHOP: 0720", `unknown field "website_version"`},
+		{"value for a map", "website_versions: 0720", "cannot unmarshal"},
+	}
+	for _, test := range tests {
+		_, err := parseYAML(test.body)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	setProductionForbidden(t, false)
+	t.Setenv(EnvMerchantID, "123456")
+	t.Setenv(EnvRISURL, "")
+	t.Setenv(EnvAPIKey, "key")
+	t.Setenv(EnvConfigKey, validConfigKey)
+	t.Setenv(EnvEnvironment, "sandbox")
+	t.Setenv(EnvDataCollectorURL, "")
+	t.Setenv(EnvVersion, "0720")
+
+	s, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.GetMerchantID() != "123456" || s.GetAPIKey() != "key" || s.GetConfigKey() != validConfigKey {
+		t.Errorf("got merchant %q, keys %q and %q", s.GetMerchantID(), s.GetAPIKey(), s.GetConfigKey())
+	}
+	if s.GetEnvironment() != EnvironmentTest || s.GetRISURL() != TestRISURL || s.GetDataCollectorURL() != TestDataCollectorURL {
+		t.Errorf("got %s, %s and %s", s.GetEnvironment(), s.GetRISURL(), s.GetDataCollectorURL())
+	}
+	if s.GetDefaultVersion() != "0720" {
+		t.Errorf("version: got %q", s.GetDefaultVersion())
+	}
+
+	t.Setenv(EnvRISURL, "http://localhost:8080")
+	t.Setenv(EnvEnvironment, "")
+	if s, err = FromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetRISURL() != "http://localhost:8080" || s.GetDataCollectorURL() != "" {
+		t.Errorf("got %s and %q", s.GetRISURL(), s.GetDataCollectorURL())
+	}
+}
+
+func TestFromEnvErrors(t *testing.T) {
+	setProductionForbidden(t, false)
+	t.Setenv(EnvMerchantID, "123456")
+	t.Setenv(EnvRISURL, "")
+	t.Setenv(EnvAPIKey, "key")
+	t.Setenv(EnvConfigKey, validConfigKey)
+	t.Setenv(EnvEnvironment, "staging")
+	t.Setenv(EnvDataCollectorURL, "")
+	t.Setenv(EnvVersion, "")
+
+	_, err := FromEnv()
+	if verr, ok := err.(*ValidationError); !ok || verr.Field != "environment" {
+		t.Errorf("unknown environment: got %v", err)
+	}
+
+	// without an environment there is no default endpoint
+	t.Setenv(EnvEnvironment, "")
+	if reasons := validationReasons(t, firstError(FromEnv())); len(reasons) != 1 || reasons[0] != "RIS URL is missing" {
+		t.Errorf("no RIS URL: got %q", reasons)
+	}
+}
+
+func firstError(_ *Settings, err error) error {
+	return err
+}
+
+// Write a settings file into a temporary directory.
+func writeSettings(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFromFile(t *testing.T) {
+	setProductionForbidden(t, false)
+	files := map[string]string{
+		"kount.json": `{
+			"merchant_id": "123456",
+			"api_key": "key",
+			"config_key": "` + validConfigKey + `",
+			"environment": "test",
+			"version": "0710",
+			"website_versions": {"SHOP": "0720"}
+		}`,
+		"kount.yaml": "merchant_id: '123456'\napi_key: key\nconfig_key: " + validConfigKey + "\nenvironment: test # sandbox\nversion: \"0710\"\nwebsite_versions:\n  SHOP: 0720\n",
+		"kount.YML":  "merchant_id: 123456\napi_key: key\nconfig_key: " + validConfigKey + "\nenvironment: test\nversion: 0710\nwebsite_versions:\n  SHOP: 0720\n",
+	}
+	for name, body := range files {
+		s, err := FromFile(writeSettings(t, name, body))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if s.GetMerchantID() != "123456" || s.GetRISURL() != TestRISURL || s.GetConfigKey() != validConfigKey {
+			t.Errorf("%s: got merchant %q, RIS URL %q, config key %q", name, s.GetMerchantID(), s.GetRISURL(), s.GetConfigKey())
+		}
+		if s.GetWebsiteVersion("SHOP") != "0720" || s.GetWebsiteVersion("OTHER") != "0710" {
+			t.Errorf("%s: got versions %q and %q", name, s.GetWebsiteVersion("SHOP"), s.GetWebsiteVersion("OTHER"))
+		}
+	}
+}
+
+func TestFromFileErrors(t *testing.T) {
+	setProductionForbidden(t, false)
+	tests := []struct {
+		name, body, want string
+	}{
+		{"kount.toml", `merchant_id = "123456"`, "unsupported file type"},
+		{"kount.json", `{"merchant_id": "123456",`, "unexpected EOF"},
+		{"kount.json", `{"merchant_id": "123456", "merchantid": "123456"}`, `unknown field "merchantid"`},
+		{"kount.json", `{"merchant_id": 123456}`, "cannot unmarshal number"},
+		{"kount.yaml", "merchant_id: 123456\n ris_url: https://risk.test.kount.net", "line 2: unexpected indentation"},
+		{"kount.yaml", "merchant_id: 123456\napi_kye: key", `unknown field "api_kye"`},
+		{"kount.yaml", "merchant_id: 12345\napi_key: key\nenvironment: test", "merchant id must be six digits"},
+	}
+	for _, test := range tests {
+		_, err := FromFile(writeSettings(t, test.name, test.body))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s %q: got %v, want %q", test.name, test.body, err, test.want)
+		}
+	}
+
+	if _, err := FromFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file: want an error")
+	}
+}
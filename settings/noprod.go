@@ -0,0 +1,9 @@
+//go:build kount_noprod
+// +build kount_noprod
+
+package settings
+
+// Builds tagged kount_noprod can never reach production endpoints.
+func init() {
+	ForbidProduction()
+}
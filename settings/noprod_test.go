@@ -0,0 +1,16 @@
+//go:build kount_noprod
+// +build kount_noprod
+
+package settings
+
+import "testing"
+
+func TestNoprodBuildTagForbidsProduction(t *testing.T) {
+	if !ProductionForbidden() {
+		t.Fatal("production is allowed in a kount_noprod build")
+	}
+	s := NewForEnvironment(EnvironmentProduction, "123456", "key", "config")
+	if err := s.CheckEndpoint(); err != ErrProductionForbidden {
+		t.Errorf("got %v, want ErrProductionForbidden", err)
+	}
+}
@@ -0,0 +1,130 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrUnknownTenant      = errors.New("settings: unknown tenant")
+	ErrDuplicateTenant    = errors.New("settings: tenant is already registered")
+	ErrCredentialConflict = errors.New("settings: credentials conflict with another tenant")
+)
+
+// Tenant is a brand or website with its own settings and SITE code.
+type Tenant struct {
+	Name     string
+	Settings *Settings
+	Site     string
+}
+
+/**
+ * Registry resolves settings by tenant name or SITE code, so services that
+ * operate several brands never pick credentials by hand. A merchant id can
+ * be shared by several tenants only if they use the same API key, and an API
+ * key can never be registered for two merchant ids. The registry keeps its
+ * own copy of each tenant's settings and hands out copies, so changes made
+ * through a resolved tenant or a request never reach another request.
+ */
+type Registry struct {
+	mu      sync.RWMutex
+	tenants map[string]Tenant
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tenants: make(map[string]Tenant)}
+}
+
+// Register a tenant. The settings' API key, if it is a JWT, must have been
+// issued for the settings' merchant id.
+func (r *Registry) Register(name string, s *Settings, site string) error {
+	credentials, err := s.GetCredentials()
+	if err != nil {
+		return fmt.Errorf("settings: tenant %q: %w", name, err)
+	}
+	if err := s.CheckAPIKey(); err == ErrAPIKeyMerchantMismatch {
+		return fmt.Errorf("settings: tenant %q: %w", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tenants[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateTenant, name)
+	}
+	if err := r.conflict(name, s.GetMerchantID(), credentials.APIKey); err != nil {
+		return err
+	}
+	r.tenants[name] = Tenant{Name: name, Settings: s.Copy(), Site: site}
+	return nil
+}
+
+/**
+ * CheckCredentials verifies that credentials resolved for a tenant, such as
+ * a key a rotating provider returned after Register, still obey the
+ * registry's sharing rules against the other tenants' current keys.
+ */
+func (r *Registry) CheckCredentials(name string, credentials Credentials) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tenants[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTenant, name)
+	}
+	return r.conflict(name, t.Settings.GetMerchantID(), credentials.APIKey)
+}
+
+// Check a tenant's merchant id and API key against the other tenants. The
+// caller holds r.mu.
+func (r *Registry) conflict(name, merchantID, apiKey string) error {
+	for _, t := range r.tenants {
+		if t.Name == name {
+			continue
+		}
+		sameMerchant := t.Settings.GetMerchantID() == merchantID
+		sameKey := t.Settings.GetAPIKey() == apiKey
+		switch {
+		case sameMerchant && !sameKey:
+			return fmt.Errorf("%w: tenant %q uses another API key for merchant %s", ErrCredentialConflict, name, merchantID)
+		case sameKey && !sameMerchant:
+			return fmt.Errorf("%w: tenant %q uses the API key of merchant %s", ErrCredentialConflict, name, t.Settings.GetMerchantID())
+		}
+	}
+	return nil
+}
+
+// Resolve a tenant by name. The tenant carries a copy of its settings.
+func (r *Registry) Resolve(name string) (Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tenants[name]
+	if !ok {
+		return Tenant{}, fmt.Errorf("%w: %q", ErrUnknownTenant, name)
+	}
+	t.Settings = t.Settings.Copy()
+	return t, nil
+}
+
+// Resolve a tenant by its SITE code. Names are searched in sorted order, so
+// the result is stable if several tenants share a site.
+func (r *Registry) ResolveWebsite(site string) (Tenant, error) {
+	for _, name := range r.Names() {
+		if t, err := r.Resolve(name); err == nil && t.Site == site {
+			return t, nil
+		}
+	}
+	return Tenant{}, fmt.Errorf("%w: no tenant for website %q", ErrUnknownTenant, site)
+}
+
+// Get the registered tenant names, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.tenants))
+	for name := range r.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
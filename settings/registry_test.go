@@ -0,0 +1,88 @@
+package settings
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistryRejectsSharedCredentials(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register("a", New("111111", "https://risk.test.kount.net", "shared", ""), "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register("b", New("222222", "https://risk.test.kount.net", "shared", ""), "B"); !errors.Is(err, ErrCredentialConflict) {
+		t.Errorf("same key, other merchant: got %v", err)
+	}
+	if err := reg.Register("c", New("111111", "https://risk.test.kount.net", "other", ""), "C"); !errors.Is(err, ErrCredentialConflict) {
+		t.Errorf("same merchant, other key: got %v", err)
+	}
+	if err := reg.Register("d", New("111111", "https://risk.test.kount.net", "shared", ""), "D"); err != nil {
+		t.Errorf("same merchant and key: got %v", err)
+	}
+	if err := reg.Register("a", New("333333", "https://risk.test.kount.net", "third", ""), "A"); !errors.Is(err, ErrDuplicateTenant) {
+		t.Errorf("duplicate name: got %v", err)
+	}
+}
+
+func TestRegistryHandsOutCopies(t *testing.T) {
+	reg := NewRegistry()
+	s := New("111111", "https://risk.test.kount.net", "key", "")
+	if err := reg.Register("a", s, "A"); err != nil {
+		t.Fatal(err)
+	}
+	s.SetCredentialProvider(StaticProvider{APIKey: "changed-after-register"})
+
+	tenant, err := reg.ResolveWebsite("A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant.Settings.SetCredentialProvider(StaticProvider{APIKey: "changed-after-resolve"})
+	tenant.Settings.SetWebsiteVersion("A", "0720")
+
+	again, err := reg.Resolve("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Settings.GetAPIKey() != "key" || again.Settings.GetWebsiteVersion("A") != "" {
+		t.Errorf("registered settings changed: %q, %q", again.Settings.GetAPIKey(), again.Settings.GetWebsiteVersion("A"))
+	}
+	if _, err := reg.Resolve("missing"); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("missing tenant: got %v", err)
+	}
+}
+
+func TestRegistryChecksResolvedCredentials(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register("a", New("111111", "https://risk.test.kount.net", "a-key", ""), "A"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KOUNTTEST_TENANT_B_API_KEY", "b-key")
+	b := New("222222", "https://risk.test.kount.net", "", "")
+	b.SetCredentialProvider(NewEnvProvider("KOUNTTEST_TENANT_B_API_KEY", ""))
+	if err := reg.Register("b", b, "B"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reg.CheckCredentials("b", Credentials{APIKey: "b-key"}); err != nil {
+		t.Errorf("registered key: got %v", err)
+	}
+	// a rotation that hands tenant b the key of merchant 111111
+	t.Setenv("KOUNTTEST_TENANT_B_API_KEY", "a-key")
+	tenant, err := reg.Resolve("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials, err := tenant.Settings.GetCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.CheckCredentials("b", credentials); !errors.Is(err, ErrCredentialConflict) {
+		t.Errorf("shared key after rotation: got %v", err)
+	}
+	if err := reg.CheckCredentials("a", Credentials{APIKey: "a-key"}); !errors.Is(err, ErrCredentialConflict) {
+		t.Errorf("other tenant now shares the key: got %v", err)
+	}
+	if err := reg.CheckCredentials("missing", credentials); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("missing tenant: got %v", err)
+	}
+}
@@ -1,26 +1,113 @@
 package settings
 
+import (
+	"net/http"
+	"time"
+)
+
 type Settings struct {
-	merchantID string
-	risURL     string
-	apiKey     string
-	configKey  string
+	merchantID        string
+	risURL            string
+	apiKey            string
+	configKey         string
+	dataCollectorURL  string
+	version           string
+	websiteVersions   map[string]string
+	transport         http.RoundTripper
+	environment       Environment
+	keyRotationPeriod time.Duration
+	credentials       CredentialProvider
 }
 
 func (s *Settings) GetMerchantID() string {
 	return s.merchantID
 }
 
+// Get the API key, from the credential provider when one is set. Empty when
+// the provider fails; GetCredentials returns the error.
 func (s *Settings) GetAPIKey() string {
-	return s.apiKey
+	c, err := s.GetCredentials()
+	if err != nil {
+		return ""
+	}
+	return c.APIKey
 }
 
 func (s *Settings) GetRISURL() string {
 	return s.risURL
 }
 
+// Get the config key, from the credential provider when one is set. Empty
+// when the provider fails; GetCredentials returns the error.
 func (s *Settings) GetConfigKey() string {
-	return s.configKey
+	c, err := s.GetCredentials()
+	if err != nil {
+		return ""
+	}
+	return c.ConfigKey
+}
+
+// Get the device data collector URL. Empty when none has been set.
+func (s *Settings) GetDataCollectorURL() string {
+	return s.dataCollectorURL
+}
+
+// Set the device data collector URL, e.g. "https://tst.kaxsdc.com".
+func (s *Settings) SetDataCollectorURL(url string) {
+	s.dataCollectorURL = url
+}
+
+// Set the RIS version requests are sent with by default, e.g. "0710". A
+// request's own SetVersion overrides it.
+func (s *Settings) SetDefaultVersion(version string) {
+	s.version = version
+}
+
+// Get the default RIS version of requests. Empty when none has been set.
+func (s *Settings) GetDefaultVersion() string {
+	return s.version
+}
+
+/**
+ * Set the RIS version used for requests to one website (SITE), so versions
+ * can be upgraded one website at a time.
+ */
+func (s *Settings) SetWebsiteVersion(site, version string) {
+	if s.websiteVersions == nil {
+		s.websiteVersions = make(map[string]string)
+	}
+	s.websiteVersions[site] = version
+}
+
+// Get the RIS version for a website, falling back to the default version.
+func (s *Settings) GetWebsiteVersion(site string) string {
+	if version, ok := s.websiteVersions[site]; ok {
+		return version
+	}
+	return s.version
+}
+
+// Set the HTTP transport used to reach RIS, e.g. a recording transport in tests.
+func (s *Settings) SetTransport(transport http.RoundTripper) {
+	s.transport = transport
+}
+
+// Get the HTTP transport used to reach RIS. Nil means http.DefaultTransport.
+func (s *Settings) GetTransport() http.RoundTripper {
+	return s.transport
+}
+
+// Get a copy of the settings that can be changed without affecting these.
+// The transport and credential provider are shared.
+func (s *Settings) Copy() *Settings {
+	c := *s
+	if s.websiteVersions != nil {
+		c.websiteVersions = make(map[string]string, len(s.websiteVersions))
+		for site, version := range s.websiteVersions {
+			c.websiteVersions[site] = version
+		}
+	}
+	return &c
 }
 
 func New(merchantID, risURL, apiKey, configKey string) *Settings {
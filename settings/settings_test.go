@@ -0,0 +1,25 @@
+package settings
+
+import "testing"
+
+func TestWebsiteVersions(t *testing.T) {
+	s := New("123456", "https://risk.test.kount.net", "key", "config")
+	if got := s.GetWebsiteVersion("SHOP"); got != "" {
+		t.Errorf("no versions set, got %q", got)
+	}
+	s.SetDefaultVersion("0710")
+	s.SetWebsiteVersion("SHOP", "0720")
+	if got := s.GetWebsiteVersion("SHOP"); got != "0720" {
+		t.Errorf("SHOP: got %q", got)
+	}
+	if got := s.GetWebsiteVersion("OTHER"); got != "0710" {
+		t.Errorf("OTHER: got %q, want the default version", got)
+	}
+
+	c := s.Copy()
+	c.SetWebsiteVersion("SHOP", "0700")
+	c.SetDefaultVersion("0700")
+	if s.GetWebsiteVersion("SHOP") != "0720" || s.GetDefaultVersion() != "0710" {
+		t.Error("changing a copy changed the original versions")
+	}
+}
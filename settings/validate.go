@@ -0,0 +1,112 @@
+package settings
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var merchantIDPattern = regexp.MustCompile(`^\d{6}$`)
+
+// minimum length of a config key
+const minConfigKeyLength = 16
+
+// ValidationError describes an invalid setting. It names the setting but
+// never includes its value, so it is safe to log.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return "settings: " + e.Field + " " + e.Reason
+}
+
+// ValidationErrors lists every invalid setting.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+/**
+ * Validate checks the merchant id (six digits), the RIS URL (absolute https,
+ * or http to a local host), that an API key is present and, if it is a JWT,
+ * was issued for the merchant id, the config key shape when one is set, and
+ * that the endpoints match the environment.
+ */
+func (s *Settings) Validate() error {
+	var errs ValidationErrors
+	add := func(field, reason string) {
+		errs = append(errs, &ValidationError{Field: field, Reason: reason})
+	}
+
+	if !merchantIDPattern.MatchString(s.merchantID) {
+		add("merchant id", "must be six digits")
+	}
+	if reason := checkURL(s.risURL); reason != "" {
+		add("RIS URL", reason)
+	}
+	if s.dataCollectorURL != "" {
+		if reason := checkURL(s.dataCollectorURL); reason != "" {
+			add("data collector URL", reason)
+		}
+	}
+	credentials, err := s.GetCredentials()
+	if err != nil || strings.TrimSpace(credentials.APIKey) == "" {
+		add("API key", "is missing")
+	} else if s.CheckAPIKey() == ErrAPIKeyMerchantMismatch {
+		add("API key", "was issued for a different merchant id")
+	}
+	if configKey := credentials.ConfigKey; configKey != "" {
+		if len(configKey) < minConfigKeyLength {
+			add("config key", "is too short")
+		}
+		for i := 0; i < len(configKey); i++ {
+			if c := configKey[i]; c <= ' ' || c > '~' {
+				add("config key", "must be printable ASCII without spaces")
+				break
+			}
+		}
+	}
+	switch s.CheckEndpoint() {
+	case ErrProductionForbidden:
+		add("endpoints", "point at production, which is forbidden in this process")
+	case ErrEnvironmentMismatch:
+		add("endpoints", "do not match the "+s.environment.String()+" environment")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Check an endpoint URL, returning why it is invalid or "".
+func checkURL(rawURL string) string {
+	if rawURL == "" {
+		return "is missing"
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "must be an absolute URL"
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && isLocalHost(u.Hostname()):
+	default:
+		return "must use https"
+	}
+	if u.RawQuery != "" || u.User != nil {
+		return "must not contain a query or credentials"
+	}
+	return ""
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "::1" || strings.HasPrefix(host, "127.")
+}
@@ -0,0 +1,107 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+/**
+ * Config describes how the simulator scores inquiries. Every inquiry starts
+ * at BaseScore; each matching rule adds its Score and may force a decision.
+ * Without a forced decision the score decides: DeclineScore and above
+ * declines, ReviewScore and above reviews, anything lower approves.
+ */
+type Config struct {
+	MerchantID   string           `json:"merchant_id"` // when set, MERC must match
+	APIKey       string           `json:"api_key"`     // when set, X-Kount-Api-Key must match
+	BaseScore    int              `json:"base_score"`
+	ReviewScore  int              `json:"review_score"`
+	DeclineScore int              `json:"decline_score"`
+	Rules        []RuleConfig     `json:"rules"`
+	Velocity     []VelocityConfig `json:"velocity"`
+}
+
+/**
+ * RuleConfig is a rule evaluated against each inquiry. Field names a request
+ * field such as "TOTL" or "B2CC", the derived field "EMAIL_DOMAIN", or a
+ * velocity counter as "COUNTER:<name>". Op is one of eq, ne, gt, gte, lt,
+ * lte, in, not_in, contains and suffix; gt to lte compare numbers and in and
+ * not_in use Values.
+ */
+type RuleConfig struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Field       string   `json:"field"`
+	Op          string   `json:"op"`
+	Value       string   `json:"value"`
+	Values      []string `json:"values"`
+	Score       int      `json:"score"`
+	Auto        string   `json:"auto"` // optional forced decision: A, R, D or E
+}
+
+// VelocityConfig counts inquiries sharing the value of a request field, e.g.
+// the same EMAL or PTOK, over a sliding window such as "1h".
+type VelocityConfig struct {
+	Name   string `json:"name"`
+	Field  string `json:"field"`
+	Window string `json:"window"`
+}
+
+// DefaultConfig returns a small rule set that exercises every kind of rule.
+func DefaultConfig() Config {
+	return Config{
+		BaseScore:    10,
+		ReviewScore:  60,
+		DeclineScore: 85,
+		Rules: []RuleConfig{
+			{ID: "1001", Description: "Order total over $1,000", Field: "TOTL", Op: "gt", Value: "100000", Score: 30},
+			{ID: "1002", Description: "Disposable email domain", Field: "EMAIL_DOMAIN", Op: "in",
+				Values: []string{"mailinator.com", "guerrillamail.com", "10minutemail.com"}, Score: 50},
+			{ID: "1003", Description: "Billing country not served", Field: "B2CC", Op: "in",
+				Values: []string{"KP", "IR"}, Auto: "D"},
+			{ID: "1004", Description: "Email velocity over 3 in 1 hour", Field: "COUNTER:EMAIL_1H", Op: "gt", Value: "3", Score: 40},
+			{ID: "1005", Description: "Card velocity over 3 in 1 hour", Field: "COUNTER:CARD_1H", Op: "gt", Value: "3", Score: 40},
+			{ID: "1006", Description: "Device velocity over 5 in 24 hours", Field: "COUNTER:DEVICE_24H", Op: "gt", Value: "5", Auto: "R"},
+		},
+		Velocity: []VelocityConfig{
+			{Name: "EMAIL_1H", Field: "EMAL", Window: "1h"},
+			{Name: "CARD_1H", Field: "PTOK", Window: "1h"},
+			{Name: "DEVICE_24H", Field: "UNIQ", Window: "24h"},
+		},
+	}
+}
+
+// LoadConfig reads a JSON config file.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(body, &cfg); err != nil {
+		return cfg, fmt.Errorf("simulator: %s: %v", path, err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks rule operators, decisions and velocity windows.
+func (c Config) Validate() error {
+	for _, rule := range c.Rules {
+		if _, ok := operators[rule.Op]; !ok {
+			return fmt.Errorf("simulator: rule %s: unknown op %q", rule.ID, rule.Op)
+		}
+		switch rule.Auto {
+		case "", "A", "R", "D", "E":
+		default:
+			return fmt.Errorf("simulator: rule %s: unknown auto decision %q", rule.ID, rule.Auto)
+		}
+	}
+	for _, v := range c.Velocity {
+		if _, err := time.ParseDuration(v.Window); err != nil {
+			return fmt.Errorf("simulator: velocity %s: %v", v.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package simulator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// rule operators by name
+var operators = map[string]func(value string, rule RuleConfig) bool{
+	"eq":     func(v string, r RuleConfig) bool { return strings.EqualFold(v, r.Value) },
+	"ne":     func(v string, r RuleConfig) bool { return !strings.EqualFold(v, r.Value) },
+	"gt":     numeric(func(a, b float64) bool { return a > b }),
+	"gte":    numeric(func(a, b float64) bool { return a >= b }),
+	"lt":     numeric(func(a, b float64) bool { return a < b }),
+	"lte":    numeric(func(a, b float64) bool { return a <= b }),
+	"in":     func(v string, r RuleConfig) bool { return contains(r.Values, v) },
+	"not_in": func(v string, r RuleConfig) bool { return v != "" && !contains(r.Values, v) },
+	"contains": func(v string, r RuleConfig) bool {
+		return strings.Contains(strings.ToLower(v), strings.ToLower(r.Value))
+	},
+	"suffix": func(v string, r RuleConfig) bool {
+		return strings.HasSuffix(strings.ToLower(v), strings.ToLower(r.Value))
+	},
+}
+
+func numeric(compare func(a, b float64) bool) func(string, RuleConfig) bool {
+	return func(v string, r RuleConfig) bool {
+		a, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return false
+		}
+		b, err := strconv.ParseFloat(r.Value, 64)
+		return err == nil && compare(a, b)
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Report whether a rule matches the inquiry fields and velocity counters.
+func (rule RuleConfig) matches(fields map[string]string, counters map[string]int) bool {
+	var value string
+	switch {
+	case strings.HasPrefix(rule.Field, "COUNTER:"):
+		count, ok := counters[strings.TrimPrefix(rule.Field, "COUNTER:")]
+		if !ok {
+			return false
+		}
+		value = strconv.Itoa(count)
+	case rule.Field == "EMAIL_DOMAIN":
+		email := fields["EMAL"]
+		if at := strings.LastIndex(email, "@"); at >= 0 {
+			value = email[at+1:]
+		}
+	default:
+		value = fields[rule.Field]
+	}
+	return operators[rule.Op](value, rule)
+}
@@ -0,0 +1,300 @@
+/**
+ * The simulator package is a local stand-in for RIS for staging environments.
+ * It accepts the form posts request.Inquiry and request.Update send, keeps
+ * transactions in memory so updates must reference an existing TRAN, and
+ * scores inquiries with configurable rules and velocity counters. Responses
+ * use the same wire formats response.Digest parses.
+ *
+ *	sim, err := simulator.New(simulator.DefaultConfig())
+ *	...
+ *	http.ListenAndServe(":8080", sim)
+ *
+ * cmd/kountsim runs it as a standalone binary.
+ */
+package simulator
+
+import (
+	"github.com/phpsquid/kount/response"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Transaction is an inquiry the simulator has answered.
+type Transaction struct {
+	ID       string
+	Inquiry  map[string]string // the inquiry fields
+	Response map[string]string // the response fields
+	Updates  []map[string]string
+	Created  time.Time
+}
+
+// transactions are kept at least this long so updates can reference them
+const minRetention = 24 * time.Hour
+
+type Simulator struct {
+	config    Config
+	windows   map[string]time.Duration
+	retention time.Duration
+	now       func() time.Time
+
+	mu           sync.Mutex
+	transactions map[string]*Transaction
+	seen         map[string][]time.Time // velocity hits by counter name and field value
+	sequence     int
+	lastPurge    time.Time
+}
+
+/**
+ * New creates a simulator, failing if the config does not validate.
+ * Transactions and velocity hits are dropped once they are older than the
+ * largest velocity window, or a day, whichever is longer.
+ */
+func New(config Config) (*Simulator, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Simulator{
+		config:       config,
+		windows:      make(map[string]time.Duration),
+		retention:    minRetention,
+		now:          time.Now,
+		transactions: make(map[string]*Transaction),
+		seen:         make(map[string][]time.Time),
+	}
+	for _, v := range config.Velocity {
+		window, _ := time.ParseDuration(v.Window)
+		s.windows[v.Name] = window
+		if window > s.retention {
+			s.retention = window
+		}
+	}
+	return s, nil
+}
+
+// Set the clock used for velocity windows and transaction times.
+func (s *Simulator) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Get a transaction by its TRAN.
+func (s *Simulator) Transaction(id string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transactions[id]
+	if !ok {
+		return Transaction{}, false
+	}
+	return t.copy(), true
+}
+
+// Copy the transaction so callers cannot change the simulator's state.
+func (t *Transaction) copy() Transaction {
+	c := *t
+	c.Inquiry = copyMap(t.Inquiry)
+	c.Response = copyMap(t.Response)
+	c.Updates = make([]map[string]string, len(t.Updates))
+	for i, update := range t.Updates {
+		c.Updates[i] = copyMap(update)
+	}
+	return c
+}
+
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fields := make(map[string]string, len(r.PostForm))
+	for key := range r.PostForm {
+		fields[key] = r.PostForm.Get(key)
+	}
+
+	status := http.StatusOK
+	var result map[string]string
+	switch {
+	case r.Method != http.MethodPost:
+		result = response.ErrorFields(fields, "261 MISSING_POST")
+	case s.config.APIKey != "" && r.Header.Get("X-Kount-Api-Key") != s.config.APIKey:
+		status = http.StatusUnauthorized
+		result = response.ErrorFields(fields, "501 UNAUTH_REQ invalid API key")
+	default:
+		result = s.Handle(fields)
+	}
+
+	body, err := response.Encode(result, fields["FRMT"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+// Handle answers the fields of a RIS request with the response fields.
+func (s *Simulator) Handle(fields map[string]string) map[string]string {
+	switch {
+	case fields["MODE"] == "":
+		return response.ErrorFields(fields, "202 MISSING_MODE")
+	case fields["MERC"] == "":
+		return response.ErrorFields(fields, "203 MISSING_MERC")
+	case s.config.MerchantID != "" && fields["MERC"] != s.config.MerchantID:
+		return response.ErrorFields(fields, "502 UNAUTH_MERC")
+	}
+
+	switch fields["MODE"] {
+	case "Q", "P", "W", "J":
+		if fields["SESS"] == "" {
+			return response.ErrorFields(fields, "204 MISSING_SESS")
+		}
+		return s.inquiry(fields)
+	case "U", "X":
+		return s.update(fields)
+	}
+	return response.ErrorFields(fields, "302 BAD_MODE Field: [MODE], Value: ["+fields["MODE"]+"]")
+}
+
+func (s *Simulator) inquiry(fields map[string]string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.purge(now)
+
+	counters := s.countVelocity(fields, now)
+	score := s.config.BaseScore
+	var auto response.Decision
+	var triggered []RuleConfig
+	for _, rule := range s.config.Rules {
+		if !rule.matches(fields, counters) {
+			continue
+		}
+		triggered = append(triggered, rule)
+		score += rule.Score
+		if d := response.Decision(rule.Auto); d.Severity() > auto.Severity() {
+			auto = d
+		}
+	}
+	if score < 1 {
+		score = 1
+	} else if score > 99 {
+		score = 99
+	}
+	if auto == "" {
+		auto = s.scoreDecision(score)
+	}
+
+	s.sequence++
+	tran := "SIM" + strconv.FormatInt(now.Unix()%1000000, 36) + strconv.Itoa(s.sequence)
+	result := response.EchoFields(fields)
+	result["TRAN"] = tran
+	result["AUTO"] = string(auto)
+	result["SCOR"] = strconv.Itoa(score)
+	result["OMNISCORE"] = strconv.FormatFloat(float64(100-score), 'f', 1, 64)
+	result["GEOX"] = fields["B2CC"]
+	result["RULES_TRIGGERED"] = strconv.Itoa(len(triggered))
+	for i, rule := range triggered {
+		result["RULE_ID_"+strconv.Itoa(i)] = rule.ID
+		result["RULE_DESCRIPTION_"+strconv.Itoa(i)] = rule.Description
+	}
+	result["COUNTERS_TRIGGERED"] = strconv.Itoa(len(s.config.Velocity))
+	for i, v := range s.config.Velocity {
+		result["COUNTER_NAME_"+strconv.Itoa(i)] = v.Name
+		result["COUNTER_VALUE_"+strconv.Itoa(i)] = strconv.Itoa(counters[v.Name])
+	}
+	result["WARNING_COUNT"] = "0"
+
+	s.transactions[tran] = &Transaction{
+		ID:       tran,
+		Inquiry:  fields,
+		Response: result,
+		Created:  now,
+	}
+	return copyMap(result)
+}
+
+func (s *Simulator) scoreDecision(score int) response.Decision {
+	switch {
+	case s.config.DeclineScore > 0 && score >= s.config.DeclineScore:
+		return response.DecisionDecline
+	case s.config.ReviewScore > 0 && score >= s.config.ReviewScore:
+		return response.DecisionReview
+	}
+	return response.DecisionApprove
+}
+
+// Record the inquiry in each velocity window and return the counts,
+// including this inquiry.
+func (s *Simulator) countVelocity(fields map[string]string, now time.Time) map[string]int {
+	counters := make(map[string]int)
+	for _, v := range s.config.Velocity {
+		value := fields[v.Field]
+		window, ok := s.windows[v.Name]
+		if value == "" || !ok {
+			continue
+		}
+		key := v.Name + "\x00" + value
+		hits := s.seen[key][:0]
+		for _, t := range s.seen[key] {
+			if now.Sub(t) < window {
+				hits = append(hits, t)
+			}
+		}
+		hits = append(hits, now)
+		s.seen[key] = hits
+		counters[v.Name] = len(hits)
+	}
+	return counters
+}
+
+// Drop expired transactions and velocity hits, at most once an hour.
+func (s *Simulator) purge(now time.Time) {
+	if now.Sub(s.lastPurge) < time.Hour {
+		return
+	}
+	for id, t := range s.transactions {
+		if now.Sub(t.Created) > s.retention {
+			delete(s.transactions, id)
+		}
+	}
+	for key, hits := range s.seen {
+		if len(hits) == 0 || now.Sub(hits[len(hits)-1]) > s.retention {
+			delete(s.seen, key)
+		}
+	}
+	s.lastPurge = now
+}
+
+func (s *Simulator) update(fields map[string]string) map[string]string {
+	tran := fields["TRAN"]
+	if tran == "" {
+		return response.ErrorFields(fields, "205 MISSING_TRAN")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transactions[tran]
+	if !ok {
+		return response.ErrorFields(fields, "305 BAD_TRAN Field: [TRAN], Value: ["+tran+"]")
+	}
+	t.Updates = append(t.Updates, fields)
+
+	// mode U acknowledges, mode X answers like the original inquiry
+	if fields["MODE"] == "X" {
+		result := copyMap(t.Response)
+		result["MODE"] = "X"
+		return result
+	}
+	result := response.EchoFields(fields)
+	result["TRAN"] = tran
+	return result
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for key, value := range m {
+		c[key] = value
+	}
+	return c
+}
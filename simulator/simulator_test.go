@@ -0,0 +1,143 @@
+package simulator
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func inquiryFields(sess, email string) map[string]string {
+	return map[string]string{"MODE": "Q", "MERC": "123456", "SESS": sess, "UNIQ": "device-" + sess, "EMAL": email, "TOTL": "1000"}
+}
+
+func TestNewRejectsUnknownOp(t *testing.T) {
+	for _, op := range []string{"", "between"} {
+		config := Config{Rules: []RuleConfig{{ID: "1", Field: "TOTL", Op: op}}}
+		if _, err := New(config); err == nil {
+			t.Errorf("op %q: want an error", op)
+		}
+	}
+}
+
+func TestVelocityAndUpdates(t *testing.T) {
+	sim, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sim.SetClock(func() time.Time { return now })
+
+	var result map[string]string
+	for i := 0; i < 4; i++ {
+		result = sim.Handle(inquiryFields("session-1", "a@example.com"))
+	}
+	if result["SCOR"] != "50" || result["RULE_ID_0"] != "1004" {
+		t.Fatalf("fourth inquiry: %v", result)
+	}
+
+	update := sim.Handle(map[string]string{"MODE": "U", "MERC": "123456", "TRAN": result["TRAN"]})
+	if update["MODE"] != "U" || update["TRAN"] != result["TRAN"] {
+		t.Fatalf("update: %v", update)
+	}
+	if bad := sim.Handle(map[string]string{"MODE": "U", "MERC": "123456", "TRAN": "nope"}); bad["ERRO"] != "305" {
+		t.Fatalf("update of unknown TRAN: %v", bad)
+	}
+
+	// past the email window the counter starts over
+	now = now.Add(2 * time.Hour)
+	if result := sim.Handle(inquiryFields("session-2", "a@example.com")); result["RULES_TRIGGERED"] != "0" {
+		t.Fatalf("after the window: %v", result)
+	}
+}
+
+func TestPurgeExpiresTransactions(t *testing.T) {
+	sim, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sim.SetClock(func() time.Time { return now })
+
+	old := sim.Handle(inquiryFields("session-1", "a@example.com"))
+	now = now.Add(25 * time.Hour)
+	recent := sim.Handle(inquiryFields("session-2", "b@example.com"))
+
+	if _, ok := sim.Transaction(old["TRAN"]); ok {
+		t.Error("expired transaction kept")
+	}
+	if _, ok := sim.Transaction(recent["TRAN"]); !ok {
+		t.Error("recent transaction dropped")
+	}
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if len(sim.seen) != 2 {
+		t.Errorf("got %d velocity keys, want the 2 of the recent inquiry", len(sim.seen))
+	}
+}
+
+func TestDeviceVelocity(t *testing.T) {
+	sim, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]string
+	for i := 0; i < 6; i++ {
+		fields := inquiryFields("session-"+strconv.Itoa(i), "user"+strconv.Itoa(i)+"@example.com")
+		fields["UNIQ"] = "device-1"
+		result = sim.Handle(fields)
+	}
+	if result["AUTO"] != "R" || result["RULE_ID_0"] != "1006" {
+		t.Errorf("sixth inquiry from one device: %v", result)
+	}
+}
+
+func TestTransactionIsACopy(t *testing.T) {
+	sim, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := sim.Handle(inquiryFields("session-1", "a@example.com"))
+	sim.Handle(map[string]string{"MODE": "U", "MERC": "123456", "TRAN": result["TRAN"], "AUTH": "A"})
+
+	tran, ok := sim.Transaction(result["TRAN"])
+	if !ok || len(tran.Updates) != 1 {
+		t.Fatalf("got %+v, %v", tran, ok)
+	}
+	tran.Inquiry["EMAL"] = "changed"
+	tran.Response["AUTO"] = "changed"
+	tran.Updates[0]["AUTH"] = "changed"
+	tran.Updates = append(tran.Updates, map[string]string{})
+
+	again, _ := sim.Transaction(result["TRAN"])
+	if again.Inquiry["EMAL"] != "a@example.com" || again.Response["AUTO"] != result["AUTO"] ||
+		again.Updates[0]["AUTH"] != "A" || len(again.Updates) != 1 {
+		t.Errorf("changing a returned transaction changed the simulator: %+v", again)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	sim, err := New(Config{MerchantID: "123456"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		fields map[string]string
+		erro   string
+	}{
+		{map[string]string{"MERC": "123456"}, "202"},
+		{map[string]string{"MODE": "Q"}, "203"},
+		{map[string]string{"MODE": "Q", "MERC": "999999"}, "502"},
+		{map[string]string{"MODE": "Q", "MERC": "123456"}, "204"},
+		{map[string]string{"MODE": "U", "MERC": "123456"}, "205"},
+		{map[string]string{"MODE": "Z", "MERC": "123456", "SESS": "s"}, "302"},
+	}
+	for _, test := range tests {
+		result := sim.Handle(test.fields)
+		if result["MODE"] != "E" || result["ERRO"] != test.erro || result["ERROR_COUNT"] != "1" {
+			t.Errorf("%v: got %v", test.fields, result)
+		}
+		if result["MERC"] != test.fields["MERC"] {
+			t.Errorf("%v: MERC not echoed: %v", test.fields, result)
+		}
+	}
+}